@@ -0,0 +1,98 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// now is replaced in tests
+var now = time.Now
+
+// An AggregateEntry summarizes every report sharing a grouping
+type AggregateEntry struct {
+	Key       string    `json:"key"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	LastError string    `json:"lastError"`
+}
+
+// An AggregateReporter keeps an in-memory table of the errors it has
+// seen, keyed by GroupingHash (or the error message), before passing
+// them on to Reporter. It doubles as an http.Handler rendering the
+// table as JSON, which makes for a quick "top errors" page.
+type AggregateReporter struct {
+	Reporter ErrorReporter
+
+	mu      sync.Mutex
+	entries map[string]*AggregateEntry
+}
+
+// Report records the error, unless it is nil, then sends it to the
+// underlying Reporter
+func (ar *AggregateReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	ar.record(err, metadataFrom(metadata))
+
+	if ar.Reporter != nil {
+		ar.Reporter.Report(ctx, err, metadata...)
+	}
+}
+
+// Flush flushes the underlying Reporter
+func (ar *AggregateReporter) Flush(ctx context.Context) error {
+	if ar.Reporter == nil {
+		return nil
+	}
+	return Flush(ctx, ar.Reporter)
+}
+
+func (ar *AggregateReporter) record(err error, metadata *BugsnagMetadata) {
+	if err == nil {
+		return
+	}
+	key := groupingKey(err, metadata)
+	t := now()
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	if ar.entries == nil {
+		ar.entries = map[string]*AggregateEntry{}
+	}
+	entry, ok := ar.entries[key]
+	if !ok {
+		entry = &AggregateEntry{Key: key, FirstSeen: t}
+		ar.entries[key] = entry
+	}
+	entry.Count++
+	entry.LastSeen = t
+	entry.LastError = err.Error()
+}
+
+// Snapshot returns a copy of the table, most frequent errors first
+func (ar *AggregateReporter) Snapshot() []AggregateEntry {
+	ar.mu.Lock()
+	entries := make([]AggregateEntry, 0, len(ar.entries))
+	for _, entry := range ar.entries {
+		entries = append(entries, *entry)
+	}
+	ar.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// ServeHTTP renders the current Snapshot as JSON
+func (ar *AggregateReporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ar.Snapshot())
+}
@@ -0,0 +1,61 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAggregateReporter(t *testing.T) {
+	start := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	clock := start
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	inner := &stubReporter{}
+	ar := &AggregateReporter{Reporter: inner}
+	ctx := context.Background()
+
+	ar.Report(ctx, errors.New("timeout talking to db"), &BugsnagMetadata{GroupingHash: "db.timeout"})
+	clock = clock.Add(time.Minute)
+	ar.Report(ctx, errors.New("timeout talking to db again"), &BugsnagMetadata{GroupingHash: "db.timeout"})
+	ar.Report(ctx, errors.New("not found"))
+	clock = clock.Add(time.Minute)
+	ar.Report(ctx, errors.New("timeout talking to db, third time"), &BugsnagMetadata{GroupingHash: "db.timeout"})
+	ar.Report(ctx, nil)
+
+	if inner.calls != 5 {
+		t.Errorf("expected every report to be delegated, got %d", inner.calls)
+	}
+
+	snapshot := ar.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(snapshot))
+	}
+	top := snapshot[0]
+	if top.Key != "db.timeout" || top.Count != 3 {
+		t.Errorf("expected db.timeout seen 3 times first, got %+v", top)
+	}
+	if !top.FirstSeen.Equal(start) || !top.LastSeen.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("unexpected first/last seen: %v / %v", top.FirstSeen, top.LastSeen)
+	}
+	if top.LastError != "timeout talking to db, third time" {
+		t.Errorf("unexpected last error %q", top.LastError)
+	}
+	if snapshot[1].Key != "not found" || snapshot[1].Count != 1 {
+		t.Errorf("expected not found seen once, got %+v", snapshot[1])
+	}
+
+	rec := httptest.NewRecorder()
+	ar.ServeHTTP(rec, httptest.NewRequest("GET", "/errors", nil))
+	var served []AggregateEntry
+	if err := json.NewDecoder(rec.Body).Decode(&served); err != nil {
+		t.Fatal(err)
+	}
+	if len(served) != 2 || served[0].Count != 3 {
+		t.Errorf("unexpected served snapshot %+v", served)
+	}
+}
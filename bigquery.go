@@ -0,0 +1,267 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBigQueryBatchSize = 100
+	bigQueryEndpoint         = "https://bigquery.googleapis.com/bigquery/v2"
+
+	// maxBigQueryBacklog bounds, in batches, how many rows are kept
+	// for the next insert while BigQuery can't be reached
+	maxBigQueryBacklog = 10
+)
+
+// A BigQueryReporter streams errors into a BigQuery table through the
+// insertAll API, as rows with the columns event_id, time, message,
+// error_class, severity, context, grouping_hash, release_stage, stack
+// and metadata (JSON encoded). Each row's insertId is its event_id, so
+// that BigQuery drops rows sent twice. Errors are batched like by a
+// LokiReporter. If a request fails, its rows are kept to be sent with
+// the next batch, or after another FlushInterval (dropping the oldest
+// once there are more than ten batches' worth). Only transport errors,
+// 5xx and 429 responses are retried: rows BigQuery rejects, or a
+// request it refuses with another 4xx, won't do better the next time
+// and are dropped.
+//
+// Authentication is left to the Doer, e.g. an *http.Client from
+// golang.org/x/oauth2/google, so this package needs no GCP libraries.
+type BigQueryReporter struct {
+	Doer Doer
+
+	Project string
+	Dataset string
+	Table   string
+
+	// Endpoint defaults to the public BigQuery API
+	Endpoint string
+
+	ReleaseStage string
+
+	FlushInterval time.Duration
+
+	// BatchSize defaults to 100
+	BatchSize int
+
+	mu         sync.Mutex
+	batchStart time.Time
+	batch      []bigQueryRow
+	timer      interface{ Stop() bool }
+}
+
+// bigQueryRow is a row of a tabledata.insertAll request
+type bigQueryRow struct {
+	InsertID string                 `json:"insertId"`
+	JSON     map[string]interface{} `json:"json"`
+}
+
+// Report adds the error to the current batch, streaming it if it is
+// due. Private errors are skipped.
+func (br *BigQueryReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = br.ReportE(ctx, err, metadata...)
+}
+
+// ReportE is like Report, but returns the error of streaming the batch,
+// if that was due
+func (br *BigQueryReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	if err == nil || isPrivate(err, metadata) {
+		return nil
+	}
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+
+	stage := ContextReleaseStage(ctx)
+	if stage == "" {
+		stage = br.ReleaseStage
+	}
+	t := now()
+	id := newUUID()
+	row := map[string]interface{}{
+		"event_id":      id,
+		"time":          t.UTC().Format(time.RFC3339Nano),
+		"message":       err.Error(),
+		"error_class":   errorClass(err, md),
+		"severity":      severity(md),
+		"context":       md.Context,
+		"grouping_hash": md.GroupingHash,
+		"release_stage": stage,
+		"stack":         stackString(errorStack(err, callers(1))),
+	}
+	if !IsZeroInterface(md.EventMetadata) {
+		if encoded, encodeErr := encodeJSON(resolveThunks(*md.EventMetadata)); encodeErr == nil {
+			row["metadata"] = strings.TrimSpace(string(encoded))
+		}
+	}
+
+	br.mu.Lock()
+	if len(br.batch) == 0 {
+		br.batchStart = t
+		br.arm()
+	}
+	br.batch = append(br.batch, bigQueryRow{InsertID: id, JSON: row})
+	var batch []bigQueryRow
+	if len(br.batch) >= br.batchSize() || t.Sub(br.batchStart) >= br.FlushInterval {
+		batch = br.take()
+	}
+	br.mu.Unlock()
+
+	if batch == nil {
+		return nil
+	}
+	return br.stream(ctx, batch)
+}
+
+// Flush streams the current batch
+func (br *BigQueryReporter) Flush(ctx context.Context) error {
+	br.mu.Lock()
+	batch := br.take()
+	br.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return br.stream(ctx, batch)
+}
+
+func (br *BigQueryReporter) batchSize() int {
+	if br.BatchSize <= 0 {
+		return defaultBigQueryBatchSize
+	}
+	return br.BatchSize
+}
+
+// stream inserts batch, putting it back in front of the current batch
+// if the request failed
+func (br *BigQueryReporter) stream(ctx context.Context, batch []bigQueryRow) error {
+	retry, err := br.insert(ctx, batch)
+	if !retry {
+		return err
+	}
+
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	// the batch was due, so it goes out with the next report
+	br.batch = append(batch, br.batch...)
+	br.batchStart = time.Time{}
+	if limit := maxBigQueryBacklog * br.batchSize(); len(br.batch) > limit {
+		br.batch = br.batch[len(br.batch)-limit:]
+	}
+	if br.timer != nil {
+		br.timer.Stop()
+		br.timer = nil
+	}
+	br.arm()
+	return err
+}
+
+// take returns the current batch and starts afresh
+func (br *BigQueryReporter) take() []bigQueryRow {
+	batch := br.batch
+	br.batch = nil
+	if br.timer != nil {
+		br.timer.Stop()
+		br.timer = nil
+	}
+	return batch
+}
+
+// arm starts a timer streaming the current batch once FlushInterval is
+// over, so that it doesn't wait for another report
+func (br *BigQueryReporter) arm() {
+	if br.FlushInterval <= 0 || br.timer != nil {
+		return
+	}
+	start := br.batchStart
+	br.timer = afterFunc(br.FlushInterval, func() { br.expire(start) })
+}
+
+// expire streams the batch started at start, unless it was already
+// taken
+func (br *BigQueryReporter) expire(start time.Time) {
+	br.mu.Lock()
+	var batch []bigQueryRow
+	if len(br.batch) > 0 && br.batchStart.Equal(start) {
+		batch = br.take()
+	}
+	br.mu.Unlock()
+
+	if batch != nil {
+		_ = br.stream(context.Background(), batch)
+	}
+}
+
+// insert streams rows, reporting whether the request failed, and so is
+// worth retrying. Invalid rows are skipped so that the others still
+// land, and reported in the error returned.
+func (br *BigQueryReporter) insert(ctx context.Context, rows []bigQueryRow) (retry bool, err error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"kind":            "bigquery#tableDataInsertAllRequest",
+		"skipInvalidRows": true,
+		"rows":            rows,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	endpoint := br.Endpoint
+	if endpoint == "" {
+		endpoint = bigQueryEndpoint
+	}
+	u := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll", strings.TrimSuffix(endpoint, "/"),
+		url.PathEscape(br.Project), url.PathEscape(br.Dataset), url.PathEscape(br.Table))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	doer := br.Doer
+	if doer == nil {
+		doer = defaultDoer
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, defaultResponseReadLimit))
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("could not insert into bigquery: %s: %s", resp.Status, respBody)
+	}
+
+	var result struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && err != io.EOF {
+		return false, err
+	}
+	if len(result.InsertErrors) == 0 {
+		return false, nil
+	}
+
+	var failures []string
+	for _, rowErr := range result.InsertErrors {
+		for _, e := range rowErr.Errors {
+			failures = append(failures, fmt.Sprintf("row %d: %s: %s", rowErr.Index, e.Reason, e.Message))
+		}
+	}
+	return false, fmt.Errorf("bigquery rejected %d of %d rows: %s", len(result.InsertErrors), len(rows), strings.Join(failures, "; "))
+}
@@ -0,0 +1,227 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBigQueryReporter(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	doer := &MockDoer{}
+	br := &BigQueryReporter{
+		Doer:          doer,
+		Project:       "acme",
+		Dataset:       "errors",
+		Table:         "events",
+		ReleaseStage:  "production",
+		FlushInterval: time.Minute,
+		BatchSize:     2,
+	}
+	ctx := context.Background()
+
+	br.Report(ctx, errors.New("first"), &BugsnagMetadata{
+		Severity:      "warning",
+		Context:       "checkout",
+		EventMetadata: &map[string]interface{}{"order": map[string]interface{}{"id": 7}},
+	})
+	br.Report(ctx, WithPrivate(errors.New("private")))
+	if n := len(doer.Requests()); n != 0 {
+		t.Fatalf("expected the first row to be batched, got %d requests", n)
+	}
+	br.Report(WithReleaseStage(ctx, "staging"), errors.New("second"))
+
+	requests := doer.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected a full batch to be inserted, got %d requests", len(requests))
+	}
+	if want := "https://bigquery.googleapis.com/bigquery/v2/projects/acme/datasets/errors/tables/events/insertAll"; requests[0].URL.String() != want {
+		t.Errorf("expected a request to %s, got %s", want, requests[0].URL)
+	}
+
+	var body struct {
+		Kind            string
+		SkipInvalidRows bool
+		Rows            []struct {
+			InsertID string
+			JSON     map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(doer.Bodies()[0], &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Kind != "bigquery#tableDataInsertAllRequest" || !body.SkipInvalidRows || len(body.Rows) != 2 {
+		t.Fatalf("unexpected insertAll request %+v", body)
+	}
+
+	first := body.Rows[0]
+	for column, want := range map[string]interface{}{
+		"time":          "2017-05-05T12:00:00Z",
+		"message":       "first",
+		"error_class":   "*errors.errorString",
+		"severity":      "warning",
+		"context":       "checkout",
+		"release_stage": "production",
+		"metadata":      `{"order":{"id":7}}`,
+	} {
+		if first.JSON[column] != want {
+			t.Errorf("expected %s to be %v, got %v", column, want, first.JSON[column])
+		}
+	}
+	if first.InsertID == "" || first.InsertID != first.JSON["event_id"] || first.InsertID == body.Rows[1].InsertID {
+		t.Errorf("expected each row's insertId to be its event ID, got %q and %q", first.InsertID, body.Rows[1].InsertID)
+	}
+	if stack, _ := first.JSON["stack"].(string); !strings.Contains(stack, "TestBigQueryReporter") {
+		t.Errorf("expected the stack, got %q", stack)
+	}
+	if second := body.Rows[1].JSON; second["release_stage"] != "staging" || second["severity"] != "error" {
+		t.Errorf("unexpected second row %v", second)
+	}
+}
+
+func TestBigQueryReporterInsertErrors(t *testing.T) {
+	doer := (&MockDoer{}).RespondWith(http.StatusOK,
+		`{"kind":"bigquery#tableDataInsertAllResponse","insertErrors":[{"index":1,"errors":[{"reason":"invalid","message":"no such field: extra"}]}]}`)
+	br := &BigQueryReporter{Doer: doer, Project: "acme", Dataset: "errors", Table: "events", FlushInterval: time.Hour}
+
+	br.Report(context.Background(), errors.New("first"))
+	br.Report(context.Background(), errors.New("second"))
+	err := br.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "rejected 1 of 2 rows: row 1: invalid: no such field: extra") {
+		t.Errorf("expected the rejected row to be reported, got %v", err)
+	}
+	if err := br.Flush(context.Background()); err != nil {
+		t.Errorf("expected nothing left to flush, got %v", err)
+	}
+}
+
+func TestBigQueryReporterKeepsFailedBatches(t *testing.T) {
+	doer := (&MockDoer{}).
+		FailWith(errors.New("connection reset")).
+		RespondWith(http.StatusServiceUnavailable, "try later").
+		RespondWith(http.StatusOK, "{}")
+	br := &BigQueryReporter{Doer: doer, Project: "acme", Dataset: "errors", Table: "events", FlushInterval: time.Hour, BatchSize: 1}
+	ctx := context.Background()
+
+	if err := br.ReportE(ctx, errors.New("first")); err == nil || err.Error() != "connection reset" {
+		t.Errorf("expected the transport error, got %v", err)
+	}
+	if err := br.ReportE(ctx, errors.New("second")); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected the failed insert, got %v", err)
+	}
+	if err := br.ReportE(ctx, errors.New("third")); err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Rows []struct{ JSON map[string]interface{} }
+	}
+	if err := json.Unmarshal(doer.Bodies()[2], &body); err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, row := range body.Rows {
+		messages = append(messages, row.JSON["message"].(string))
+	}
+	if got := strings.Join(messages, ","); got != "first,second,third" {
+		t.Errorf("expected the failed rows to be sent again, got %s", got)
+	}
+	if err := br.Flush(ctx); err != nil || len(doer.Requests()) != 3 {
+		t.Errorf("expected nothing left to flush, got %v after %d requests", err, len(doer.Requests()))
+	}
+}
+
+func TestBigQueryReporterDropsRefusedBatches(t *testing.T) {
+	doer := (&MockDoer{}).
+		RespondWith(http.StatusTooManyRequests, "slow down").
+		RespondWith(http.StatusForbidden, "access denied").
+		RespondWith(http.StatusOK, "{}")
+	br := &BigQueryReporter{Doer: doer, Project: "acme", Dataset: "errors", Table: "events", FlushInterval: time.Hour, BatchSize: 1}
+	ctx := context.Background()
+
+	if err := br.ReportE(ctx, errors.New("throttled")); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("expected the throttled insert, got %v", err)
+	}
+	if err := br.ReportE(ctx, errors.New("forbidden")); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected the refused insert, got %v", err)
+	}
+	if len(br.batch) != 0 {
+		t.Errorf("expected the refused batch to be dropped, got %d rows", len(br.batch))
+	}
+	if err := br.ReportE(ctx, errors.New("allowed")); err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Rows []struct{ JSON map[string]interface{} }
+	}
+	if err := json.Unmarshal(doer.Bodies()[2], &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Rows) != 1 || body.Rows[0].JSON["message"] != "allowed" {
+		t.Errorf("expected only the new row to be sent, got %+v", body.Rows)
+	}
+}
+
+func TestBigQueryReporterBacklogLimit(t *testing.T) {
+	doer := (&MockDoer{}).FailWith(errors.New("connection refused"))
+	br := &BigQueryReporter{Doer: doer, FlushInterval: time.Hour, BatchSize: 1}
+
+	for i := 0; i < maxBigQueryBacklog+5; i++ {
+		br.Report(context.Background(), errors.New("unsent"))
+	}
+	if n := len(br.batch); n != maxBigQueryBacklog {
+		t.Errorf("expected the backlog to be capped at %d rows, got %d", maxBigQueryBacklog, n)
+	}
+}
+
+func TestBigQueryReporterFlushInterval(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+	timers := stubAfterFunc(t)
+
+	doer := (&MockDoer{}).FailWith(errors.New("connection reset")).RespondWith(http.StatusOK, "{}")
+	br := &BigQueryReporter{Doer: doer, Project: "acme", Dataset: "errors", Table: "events", FlushInterval: time.Minute}
+	ctx := context.Background()
+
+	br.Report(ctx, errors.New("rare"))
+	clock = clock.Add(time.Second)
+	br.Report(ctx, errors.New("rarer"))
+	if len(*timers) != 1 || (*timers)[0].d != time.Minute {
+		t.Fatalf("expected a single timer for the interval, got %v", *timers)
+	}
+
+	// nothing else is reported, the timer streams the batch anyway
+	clock = clock.Add(time.Minute)
+	(*timers)[0].f()
+	if len(doer.Requests()) != 1 {
+		t.Fatalf("expected an insert on time, got %d", len(doer.Requests()))
+	}
+
+	// which failed, so another timer tries again
+	if len(*timers) != 2 {
+		t.Fatalf("expected a timer to retry the failed insert, got %v", *timers)
+	}
+	(*timers)[1].f()
+	var body struct {
+		Rows []struct{ JSON map[string]interface{} }
+	}
+	if err := json.Unmarshal(doer.Bodies()[1], &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(doer.Requests()) != 2 || len(body.Rows) != 2 {
+		t.Errorf("expected the batch to be inserted again, got %d requests, %+v", len(doer.Requests()), body)
+	}
+
+	if err := br.Flush(ctx); err != nil || len(doer.Requests()) != 2 {
+		t.Errorf("expected nothing left to flush, got %v after %d requests", err, len(doer.Requests()))
+	}
+}
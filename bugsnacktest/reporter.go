@@ -0,0 +1,41 @@
+// Package bugsnacktest provides an ErrorReporter for the tests of code
+// reporting errors with bugsnack, much like net/http/httptest does for
+// HTTP handlers.
+package bugsnacktest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fromatob/bugsnack"
+)
+
+// A TestReporter turns reported errors into test failures, so that an
+// error mishandled by the code under test shows up in the test output
+// without anything going over the network. With Log set, errors are
+// only logged instead.
+type TestReporter struct {
+	TB  testing.TB
+	Log bool
+}
+
+// NewTestReporter returns a TestReporter failing t for every error
+func NewTestReporter(t testing.TB) bugsnack.ErrorReporter {
+	return &TestReporter{TB: t}
+}
+
+// Report fails (or logs to) the test
+func (tr *TestReporter) Report(_ context.Context, err error, metadata ...interface{}) {
+	tr.TB.Helper()
+	severity := "error"
+	if len(metadata) > 0 {
+		if md, ok := metadata[0].(*bugsnack.BugsnagMetadata); ok && md.Severity != "" {
+			severity = md.Severity
+		}
+	}
+	if tr.Log {
+		tr.TB.Logf("bugsnack: reported %s: %+v", severity, err)
+		return
+	}
+	tr.TB.Errorf("bugsnack: reported %s: %+v", severity, err)
+}
@@ -0,0 +1,48 @@
+package bugsnacktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fromatob/bugsnack"
+)
+
+// fakeTB records what a TestReporter does with a test
+type fakeTB struct {
+	testing.TB
+	errors, logs []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Logf(format string, args ...interface{}) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func TestTestReporter(t *testing.T) {
+	ctx := context.Background()
+
+	tb := &fakeTB{}
+	NewTestReporter(tb).Report(ctx, errors.New("boom"), &bugsnack.BugsnagMetadata{Severity: "warning"})
+	if len(tb.errors) != 1 || len(tb.logs) != 0 {
+		t.Fatalf("expected the test to fail, got errors %v and logs %v", tb.errors, tb.logs)
+	}
+	if want := "bugsnack: reported warning: boom"; tb.errors[0] != want {
+		t.Errorf("expected %q, got %q", want, tb.errors[0])
+	}
+
+	tb = &fakeTB{}
+	(&TestReporter{TB: tb, Log: true}).Report(ctx, errors.New("boom"))
+	if len(tb.errors) != 0 || len(tb.logs) != 1 {
+		t.Fatalf("expected the error to be logged, got errors %v and logs %v", tb.errors, tb.logs)
+	}
+	if want := "bugsnack: reported error: boom"; tb.logs[0] != want {
+		t.Errorf("expected %q, got %q", want, tb.logs[0])
+	}
+}
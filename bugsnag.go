@@ -7,162 +7,922 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"os"
 	"reflect"
-	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 )
 
-const clientVersion = "0.0.3"
+// Version is the version of bugsnack, as reported to bugsnag
+const Version = "0.0.3"
+
+const defaultEndpoint = "https://notify.bugsnag.com"
+
+const defaultResponseReadLimit = 1024
+
+// eventTimeFormat is the ISO 8601 format of event timestamps
+const eventTimeFormat = "2006-01-02T15:04:05.000Z07:00"
 
 // BugsnagReporter is an implementation of ErrorReporter that fires to
 // BugSnag
 type BugsnagReporter struct {
-	Doer         Doer
-	APIKey       string
+	// Doer sends requests to bugsnag, defaulting to http.DefaultClient
+	Doer       Doer
+	APIKey     string
+	AppVersion string
+
+	// ReleaseStage is the stage errors are reported from, unless the
+	// context says otherwise (see WithReleaseStage)
 	ReleaseStage string
 
+	// RequestBuilder, if set, builds the request carrying each encoded
+	// payload, replacing the default POST to Endpoint. This allows e.g.
+	// going through a gateway with its own path and headers.
+	RequestBuilder func(ctx context.Context, body []byte) (*http.Request, error)
+
+	// NotifyReleaseStages, when not empty, lists the release stages
+	// errors are sent from; in any other stage Report does nothing
+	NotifyReleaseStages []string
+
+	// Endpoint is the URL events are posted to, defaulting to the
+	// notify endpoint of the Region
+	Endpoint string
+
+	// Region selects where events are stored, defaulting to the US
+	Region Region
+
+	// DeviceInfo, if set, supplies extra values for the event's
+	// device section (e.g. pod name, node and namespace), overriding
+	// the defaults. Wrap it with CachedDeviceInfo if it is expensive.
+	DeviceInfo func() map[string]interface{}
+
+	// MessageFunc, if set, renders the message of each event instead
+	// of err.Error(), e.g. to show only the top-level message of a
+	// long wrap chain
+	MessageFunc func(err error) string
+
+	// MaxMessageBytes, if set, bounds the length of messages, which
+	// are cut short at a character boundary and end with an ellipsis
+	// if it fits. The full message is kept under the "error" tab.
+	MaxMessageBytes int
+
+	// ErrorCodeInMessage prefixes messages with the metadata's
+	// ErrorCode, e.g. "[ERR_DB_CONN_1001] connection refused"
+	ErrorCodeInMessage bool
+
+	// CaptureVerbose keeps the %+v rendering of errors which implement
+	// fmt.Formatter but carry no stack bugsnack understands, under the
+	// "error" tab, so that at least a textual stack is preserved
+	CaptureVerbose bool
+
+	// GroupingFrameDepth, if set, groups events without a GroupingHash
+	// by the file and line of the innermost GroupingFrameDepth frames
+	// of their stack which belong to the project (see ProjectPackages),
+	// so that groups survive changing messages and library upgrades
+	GroupingFrameDepth int
+
+	// ProjectPackages lists the import paths of the application's own
+	// packages. By default, frames outside the standard library, the
+	// module cache and vendor directories are taken to be the
+	// application's.
+	ProjectPackages []string
+
+	// GroupByNormalizedMessage groups events without a GroupingHash by
+	// their message with IDs, numbers and the like taken out (see
+	// NormalizeMessage), using Normalizers or else DefaultNormalizers
+	GroupByNormalizedMessage bool
+	Normalizers              []Normalizer
+
+	// CompressMetadataOver, if set, gzips and base64 encodes metadata
+	// strings and byte slices longer than this many bytes (e.g. a
+	// rendered page). Each gets a sibling "<key>_encoding" key set to
+	// "gzip+base64".
+	CompressMetadataOver int
+
+	// Hints attach a suggested action (e.g. "check the DB connection
+	// pool") under the "error" tab to the errors they match
+	Hints map[ErrorMatcher]string
+
+	// ContextFunc, if set, derives the event context (e.g. a route
+	// or job name) when the metadata does not provide one
+	ContextFunc func(ctx context.Context, err error) string
+
+	// Classifier, if set, picks the severity and error class of each
+	// error (e.g. context.DeadlineExceeded is only a warning), so the
+	// policy lives in one place. Empty results are ignored, and values
+	// given in the metadata always win.
+	Classifier func(err error) (severity, class string)
+
+	// MaxMetadataDepth and MaxMetadataItems bound how deeply nested
+	// and how large each part of the EventMetadata may be; anything
+	// beyond is replaced by a "[pruned]" marker. Zero means no limit.
+	MaxMetadataDepth int
+	MaxMetadataItems int
+
+	// SplitJoinedErrors sends each error joined by errors.Join (or
+	// anything else with an Unwrap() []error method) as an event of
+	// its own. By default, they are sent as extra exceptions of a
+	// single event, after the joined error itself.
+	SplitJoinedErrors bool
+
+	// MaxStackDepth, if set, caps the number of frames sent for each
+	// stack trace, keeping the innermost ones and ending with a
+	// "... N frames omitted" marker, so that runaway recursion does
+	// not blow up the payload
+	MaxStackDepth int
+
+	// SkipCanceled drops errors caused by context.Canceled or
+	// context.DeadlineExceeded, which usually just mean a client went
+	// away. New sets it unless Config.ReportCanceled is set.
+	SkipCanceled bool
+
+	// Dependencies lists module paths (e.g. github.com/lib/pq, or
+	// github.com/aws for everything below it) whose versions, as built
+	// into the binary, are attached under a "dependencies" tab
+	Dependencies []string
+
+	// DisableBuildInfo stops the VCS revision, dirty flag and commit
+	// time of the binary being attached under a "build" tab
+	DisableBuildInfo bool
+
+	// MaxRetries is how many more times delivery is attempted after a
+	// network error, 5xx or 429 response. The wait between attempts
+	// starts at RetryBackoff (default 500ms) and doubles each time.
+	// Jitter randomizes each wait between zero and its full length, so
+	// that many instances failing at once don't retry in lockstep.
+	// RandSource, if set, makes the jitter deterministic.
+	MaxRetries   int
+	RetryBackoff time.Duration
+	Jitter       bool
+	RandSource   rand.Source
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	warnOnce sync.Once
+
+	// CoalesceWindow, if set, folds reports of the same error (going by
+	// GroupingHash, or else the message) which follow one another
+	// within the window into a count, sent under a "coalesced" tab with
+	// the next report of a different error. This smooths out tight
+	// retry loops. Folded repeats are not archived, nor counted against
+	// MaxTotalReports.
+	CoalesceWindow time.Duration
+
+	coalesceMu     sync.Mutex
+	lastKey        string
+	lastReportedAt time.Time
+	coalesced      int
+
+	// MaxTotalReports, if set, caps how many reports are sent over the
+	// reporter's lifetime, so that a runaway loop cannot burn through
+	// the quota. Later errors are dropped.
+	MaxTotalReports int
+
+	totalReports int64
+	limitOnce    sync.Once
+
+	// Queue, if set, makes delivery asynchronous and durable: Report
+	// writes each payload to the queue and returns, while a background
+	// goroutine sends it on. Call ReplayQueue on startup to send the
+	// payloads left behind by an earlier process, and Flush before
+	// exiting. Payloads bugsnag rejects outright are dropped.
+	Queue *DiskQueue
+
+	queueMu  sync.Mutex
+	draining bool
+	pending  bool
+
+	// Logs, if set, attaches its recent lines under a "logs" tab
+	Logs *LogBuffer
+
+	// ResponseReadLimit bounds how much of a response is read, and so
+	// how much of an error response ends up in the error passed to
+	// Backup. It defaults to 1024 bytes.
+	ResponseReadLimit int
+
+	// UserAgent is sent with every request unless the RequestBuilder
+	// sets one, defaulting to Bugsnack/<Version>
+	UserAgent string
+
+	// SignRequest, if set, is called with the exact body being sent
+	// and returns a header to add to the request, e.g. an HMAC
+	// signature required by a self-hosted collector
+	SignRequest func(body []byte) (headerName, headerValue string)
+
 	Backup ErrorReporter
+
+	// Archive, if set, receives every error reported in a notifying
+	// release stage, whether or not it reaches bugsnag, e.g. to keep a
+	// local record for compliance. Unlike Backup, it is not only used
+	// when delivery fails.
+	Archive ErrorReporter
 }
+
+// BugsnagUser identifies a user affected by an error
+type BugsnagUser struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// CachedDeviceInfo wraps fn so that it is only called once,
+// returning the same values on every later call
+func CachedDeviceInfo(fn func() map[string]interface{}) func() map[string]interface{} {
+	var once sync.Once
+	var info map[string]interface{}
+	return func() map[string]interface{} {
+		once.Do(func() {
+			info = fn()
+		})
+		return info
+	}
+}
+
 type BugsnagMetadata struct {
-	ErrorClass    string
-	Context       string
-	GroupingHash  string
-	Severity      string
+	ErrorClass   string
+	Context      string
+	GroupingHash string
+	Severity     string
+
+	// EventMetadata may hold func() interface{} values, which are only
+	// called if the event is sent, to defer computing costly metadata
 	EventMetadata *map[string]interface{}
+
+	// User is the user affected by the error
+	User *BugsnagUser
+
+	// ErrorCode is an internal code for the error (e.g.
+	// ERR_DB_CONN_1001), such as support articles refer to. It is sent
+	// as an "error_code" tag, and see BugsnagReporter.ErrorCodeInMessage.
+	ErrorCode string
+
+	// Tags are simple labels to filter events by, sent under a "tags"
+	// tab. They are merged key by key with those stored in the context
+	// by WithTags.
+	Tags map[string]string
+
+	// Private errors are kept on the premises: reporters sending to
+	// external services (bugsnag, webhooks, ...) skip them, while
+	// local ones (writers, files, Archive) still record them
+	Private bool
+
+	// Unhandled marks errors which were not dealt with by the
+	// application, such as recovered panics
+	Unhandled bool
+
+	// SeverityReason, if set, explains the severity of the event
+	SeverityReason *SeverityReason
 }
 
 func (metadata *BugsnagMetadata) populateMetadata(err error) {
 	if metadata.ErrorClass == "" {
-		metadata.ErrorClass = reflect.TypeOf(err).String()
+		metadata.ErrorClass = reflect.TypeOf(withoutWrappers(err)).String()
 	}
 	if metadata.Severity == "" {
 		metadata.Severity = "error"
 	}
 }
 
+// ErrMisconfigured is returned by ReportE when the reporter has no
+// APIKey, Endpoint, RequestBuilder or Backup, so errors could not go
+// anywhere
+var ErrMisconfigured = errors.New("bugsnack: BugsnagReporter has no APIKey, Endpoint or Backup")
+
+// ErrReportLimit is returned by ReportE once a reporter has sent
+// MaxTotalReports reports
+var ErrReportLimit = errors.New("bugsnack: MaxTotalReports reached, no longer reporting")
+
+// stderr is where errors end up when there is nowhere else to send them
+var stderr io.Writer = os.Stderr
+
+// Report sends the error to bugsnag, falling back to the Backup
+// reporter (or stderr, if there is none) if anything goes wrong. A
+// misconfigured reporter writes every error to stderr instead, after
+// warning about it once. Past MaxTotalReports, errors are dropped after
+// a single notice on stderr.
 func (er *BugsnagReporter) Report(ctx context.Context, newErr error, meta ...interface{}) {
-	newErr = errors.WithStack(newErr)
-	metadata := &BugsnagMetadata{}
+	_, err := er.report(ctx, newErr, meta...)
+	if err == ErrMisconfigured {
+		er.warnOnce.Do(func() {
+			fmt.Fprintf(stderr, "%s, writing errors to stderr\n", ErrMisconfigured)
+		})
+		(&WriterReporter{Writer: stderr}).Report(ctx, newErr, meta...)
+		return
+	}
+	if err == ErrReportLimit {
+		er.limitOnce.Do(func() {
+			fmt.Fprintln(stderr, ErrReportLimit)
+		})
+		return
+	}
+	if err != nil {
+		er.backup().Report(ctx, err)
+	}
+}
 
-	if len(meta) > 0 {
-		metadata = meta[0].(*BugsnagMetadata)
+func (er *BugsnagReporter) backup() ErrorReporter {
+	if er.Backup == nil {
+		return &WriterReporter{Writer: stderr}
 	}
+	return er.Backup
+}
 
-	payload := er.newPayload(newErr, metadata)
-	var b bytes.Buffer
-	err := json.NewEncoder(&b).Encode(payload)
+// coalesce reports whether an error with the given grouping key seen
+// at t repeats the previous one, and should only be counted. Otherwise
+// it returns the count of the previous error's repeats, if any, for
+// its report to carry.
+func (er *BugsnagReporter) coalesce(key string, t time.Time) (bool, map[string]interface{}) {
+	er.coalesceMu.Lock()
+	defer er.coalesceMu.Unlock()
+
+	if key == er.lastKey && !er.lastReportedAt.IsZero() && t.Sub(er.lastReportedAt) < er.CoalesceWindow {
+		er.lastReportedAt = t
+		er.coalesced++
+		return true, nil
+	}
+
+	var tab map[string]interface{}
+	if er.coalesced > 0 {
+		tab = map[string]interface{}{"count": er.coalesced, "group": er.lastKey}
+	}
+	er.lastKey, er.lastReportedAt, er.coalesced = key, t, 0
+	return false, tab
+}
+
+// misconfigured reports whether errors could not go anywhere
+func (er *BugsnagReporter) misconfigured() bool {
+	return er.APIKey == "" && er.Endpoint == "" && er.RequestBuilder == nil && er.Backup == nil
+}
+
+// ReportE sends the error to bugsnag, returning any error
+// encountered instead of using the Backup reporter
+func (er *BugsnagReporter) ReportE(ctx context.Context, newErr error, meta ...interface{}) error {
+	_, err := er.report(ctx, newErr, meta...)
+	return err
+}
+
+// ReportWithID is like ReportE, but also returns the ID of the event
+// sent (found under the "event" tab in bugsnag), e.g. to show users as
+// an error reference. It returns "" if nothing was sent.
+func (er *BugsnagReporter) ReportWithID(ctx context.Context, newErr error, meta ...interface{}) (string, error) {
+	return er.report(ctx, newErr, meta...)
+}
+
+// Flush sends anything left in the Queue, then flushes the Backup and
+// Archive reporters
+func (er *BugsnagReporter) Flush(ctx context.Context) error {
+	if er.Queue != nil {
+		if err := er.Queue.Drain(ctx, er.send); err != nil {
+			return err
+		}
+	}
+	return (&MultiReporter{Reporters: []ErrorReporter{er.Backup, er.Archive}}).Flush(ctx)
+}
+
+// ReplayQueue starts sending whatever an earlier process left in the
+// Queue in the background, rather than waiting for the next report.
+// Without a Queue it does nothing.
+func (er *BugsnagReporter) ReplayQueue() {
+	if er.Queue != nil {
+		er.drainQueue()
+	}
+}
+
+func (er *BugsnagReporter) report(ctx context.Context, newErr error, meta ...interface{}) (eventID string, err error) {
+	if !er.notifies(er.releaseStage(ctx)) || (er.SkipCanceled && isCanceled(newErr)) {
+		return "", nil
+	}
+	// the time of the report, not of its (possibly retried) delivery
+	at := now()
+	metadata := metadataFrom(meta)
+	if tags := ContextTags(ctx); len(tags) > 0 {
+		metadata = mergeMetadata(&BugsnagMetadata{Tags: tags}, metadata)
+	}
+	if attached := errorMetadata(newErr); attached != nil {
+		metadata = mergeMetadata(attached, metadata)
+	}
+	if er.Classifier != nil {
+		severity, class := er.Classifier(newErr)
+		metadata = mergeMetadata(&BugsnagMetadata{Severity: severity, ErrorClass: class}, metadata)
+	}
+	// a copy, as the caller's metadata may be read by other reporters
+	// at the same time (e.g. in a MultiReporter) and is filled in below
+	metadata = mergeMetadata(metadata, nil)
+	if metadata.ErrorCode != "" {
+		metadata = mergeMetadata(&BugsnagMetadata{Tags: map[string]string{errorCodeTag: metadata.ErrorCode}}, metadata)
+	}
+	// before anything else, so that repeats folded into a count are
+	// neither archived nor held against MaxTotalReports
+	if er.CoalesceWindow > 0 {
+		coalesce, tab := er.coalesce(groupingKey(newErr, metadata), at)
+		if coalesce {
+			return "", nil
+		}
+		if tab != nil {
+			metadata = mergeMetadata(metadata, &BugsnagMetadata{EventMetadata: &map[string]interface{}{"coalesced": tab}})
+		}
+	}
+
+	if er.Archive != nil {
+		er.Archive.Report(ctx, newErr, meta...)
+	}
+	if isPrivate(newErr, meta) {
+		return "", nil
+	}
+	if er.misconfigured() {
+		return "", ErrMisconfigured
+	}
+	if er.MaxTotalReports > 0 && atomic.AddInt64(&er.totalReports, 1) > int64(er.MaxTotalReports) {
+		return "", ErrReportLimit
+	}
+
+	// errors which carry their own stack don't need another one
+	var pcs []uintptr
+	if !hasStack(newErr) {
+		pcs = callers(1)
+	}
+
+	// split events each have their own ID, the first one stands for all
+	eventID = newUUID()
+	var events []*map[string]interface{}
+	if leaves := joinedLeaves(newErr); er.SplitJoinedErrors && len(leaves) > 1 {
+		for i, leaf := range leaves {
+			id := eventID
+			if i > 0 {
+				id = newUUID()
+			}
+			leafMetadata := mergeMetadata(metadata, nil)
+			leafMetadata.populateMetadata(leaf)
+			events = append(events, er.newEvent(ctx, id, leaf, pcs, at, leafMetadata))
+		}
+	} else {
+		metadata.populateMetadata(newErr)
+		events = append(events, er.newEvent(ctx, eventID, newErr, pcs, at, metadata))
+	}
+
+	payload := er.newPayload(events...)
+	body, err := er.encodePayload(ctx, payload)
 	if err != nil {
-		er.Backup.Report(ctx, err)
+		return "", err
+	}
+
+	// the key stays the same however often delivery is tried
+	key := newUUID()
+	if er.Queue != nil {
+		if err := er.Queue.Enqueue(key, body); err != nil {
+			return "", err
+		}
+		er.drainQueue()
+		return eventID, nil
+	}
+	return eventID, er.send(ctx, key, body)
+}
+
+// drainQueue sends the Queue on in the background, unless that is
+// already happening, in which case it goes round once more
+func (er *BugsnagReporter) drainQueue() {
+	er.queueMu.Lock()
+	defer er.queueMu.Unlock()
+
+	er.pending = true
+	if er.draining {
 		return
 	}
+	er.draining = true
+
+	go func() {
+		for {
+			er.queueMu.Lock()
+			if !er.pending {
+				er.draining = false
+				er.queueMu.Unlock()
+				return
+			}
+			er.pending = false
+			er.queueMu.Unlock()
+
+			// not the caller's context, which is likely done by now
+			if err := er.Queue.Drain(context.Background(), er.send); err != nil {
+				er.backup().Report(context.Background(), err)
+			}
+		}
+	}()
+}
+
+// encodePayload encodes payload as JSON. If that fails, the events'
+// metaData (the usual culprit) is replaced with a note saying it was
+// dropped, so that the error itself still reaches bugsnag. A panic
+// while encoding (e.g. in a MarshalJSON method) is treated the same
+// way, but the Backup reporter is told about it too, since it points
+// at a bug rather than bad data.
+func (er *BugsnagReporter) encodePayload(ctx context.Context, payload *map[string]interface{}) ([]byte, error) {
+	body, err := encodeJSON(payload)
+	if err == nil {
+		return body, nil
+	}
+	if _, ok := err.(*encodePanicError); ok {
+		er.backup().Report(ctx, err)
+	}
+
+	for _, event := range (*payload)["events"].([]*map[string]interface{}) {
+		(*event)["metaData"] = map[string]interface{}{
+			"bugsnack": map[string]interface{}{
+				"metaDataDropped": err.Error(),
+			},
+		}
+	}
+	return encodeJSON(payload)
+}
+
+// encodeJSON encodes v, turning a panic into an *encodePanicError
+func encodeJSON(v interface{}) (body []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			body, err = nil, &encodePanicError{value: r}
+		}
+	}()
+
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// encodePanicError is a panic recovered while encoding a payload
+type encodePanicError struct {
+	value interface{}
+}
+
+func (e *encodePanicError) Error() string {
+	return fmt.Sprintf("bugsnack: panic encoding payload: %v", e.value)
+}
 
-	req, err := http.NewRequest(http.MethodPost, "https://notify.bugsnag.com", &b)
+// attempt makes a single attempt at delivering an encoded payload,
+// reporting whether it is worth retrying if it fails
+func (er *BugsnagReporter) attempt(ctx context.Context, body []byte, idempotencyKey string) (retry bool, err error) {
+	req, err := er.request(ctx, body)
 	if err != nil {
-		er.Backup.Report(ctx, err)
-		return
+		return false, err
 	}
-	req = req.WithContext(ctx)
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
 
-	resp, err := er.Doer.Do(req)
+	resp, err := er.doer().Do(req)
 	if err != nil {
-		er.Backup.Report(ctx, err)
-		return
+		return true, err
+	}
+	limit := int64(er.ResponseReadLimit)
+	if limit <= 0 {
+		limit = defaultResponseReadLimit
 	}
 	defer func() {
-		_, err = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1024))
-		if err != nil {
-			er.Backup.Report(ctx, err)
+		_, drainErr := io.Copy(ioutil.Discard, io.LimitReader(resp.Body, limit))
+		closeErr := resp.Body.Close()
+		if err == nil {
+			err = drainErr
 		}
-		err = resp.Body.Close()
-		if err != nil {
-			er.Backup.Report(ctx, err)
+		if err == nil {
+			err = closeErr
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		er.Backup.Report(ctx, errors.New("could not report to bugsnag"))
-		return
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, limit))
+		err = errors.Errorf("could not report to bugsnag: %s: %s", resp.Status, respBody)
+		switch {
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			return true, err
+		case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge:
+			// the payload itself is at fault, unlike e.g. a bad API key
+			return false, &UndeliverableError{Err: err}
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// request builds a signed request carrying body
+func (er *BugsnagReporter) request(ctx context.Context, body []byte) (*http.Request, error) {
+	newRequest := er.RequestBuilder
+	if newRequest == nil {
+		newRequest = er.newRequest
+	}
+	req, err := newRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", er.userAgent())
+	}
+	if er.SignRequest != nil {
+		req.Header.Set(er.SignRequest(body))
+	}
+	return req, nil
+}
+
+func (er *BugsnagReporter) userAgent() string {
+	if er.UserAgent == "" {
+		return "Bugsnack/" + Version
+	}
+	return er.UserAgent
+}
+
+// newRequest is the default RequestBuilder
+func (er *BugsnagReporter) newRequest(ctx context.Context, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, er.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (er *BugsnagReporter) notifies(releaseStage string) bool {
+	if len(er.NotifyReleaseStages) == 0 {
+		return true
+	}
+	for _, stage := range er.NotifyReleaseStages {
+		if stage == releaseStage {
+			return true
+		}
+	}
+	return false
+}
+
+func (er *BugsnagReporter) endpoint() string {
+	if er.Endpoint == "" {
+		return er.Region.NotifyEndpoint()
+	}
+	return er.Endpoint
 }
 
-func (er *BugsnagReporter) newPayload(err error, metadata *BugsnagMetadata) *map[string]interface{} {
-	metadata.populateMetadata(err)
+func (er *BugsnagReporter) doer() Doer {
+	if er.Doer == nil {
+		return defaultDoer
+	}
+	return er.Doer
+}
 
+func (er *BugsnagReporter) newPayload(events ...*map[string]interface{}) *map[string]interface{} {
 	return &map[string]interface{}{
 		"apiKey": er.APIKey,
 
 		"notifier": &map[string]interface{}{
 			"name":    "Bugsnack/Bugsnag",
 			"url":     "https://github.com/fromatob/bugsnack",
-			"version": clientVersion,
+			"version": Version,
 		},
 
-		"events": []*map[string]interface{}{
-			er.newEvent(err, metadata),
-		},
+		"events": append([]*map[string]interface{}{}, events...),
 	}
 }
 
-func (er *BugsnagReporter) newEvent(err error, metadata *BugsnagMetadata) *map[string]interface{} {
-	type stackTracer interface {
-		StackTrace() errors.StackTrace
+func (er *BugsnagReporter) message(err error) string {
+	if er.MessageFunc != nil {
+		return er.MessageFunc(err)
 	}
+	return err.Error()
+}
 
-	host, _ := os.Hostname()
-	stacktrace := err.(stackTracer).StackTrace()[1:]
+// ellipsis marks truncated messages
+const ellipsis = "…"
+
+// truncateMessage cuts msg down to at most max bytes (if max is set),
+// without splitting a multi-byte character. The ellipsis is left out
+// when max is too small to hold it.
+func truncateMessage(msg string, max int) string {
+	if max <= 0 || len(msg) <= max {
+		return msg
+	}
+	marker := ellipsis
+	if max < len(marker) {
+		marker = ""
+	}
+	cut := max - len(marker)
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + marker
+}
+
+func (er *BugsnagReporter) newException(err error, class, message string, pcs []uintptr) *map[string]interface{} {
+	return &map[string]interface{}{
+		"errorClass": class,
+		"message":    truncateMessage(message, er.MaxMessageBytes),
+		"stacktrace": capStack(formatStack(errorStack(err, pcs)), er.MaxStackDepth),
+	}
+}
+
+func (er *BugsnagReporter) newEvent(ctx context.Context, id string, err error, pcs []uintptr, at time.Time, metadata *BugsnagMetadata) *map[string]interface{} {
+	message := er.message(err)
+	if er.ErrorCodeInMessage && metadata.ErrorCode != "" {
+		message = "[" + metadata.ErrorCode + "] " + message
+	}
+	exceptions := []*map[string]interface{}{
+		er.newException(err, metadata.ErrorClass, message, pcs),
+	}
+	if leaves := joinedLeaves(err); len(leaves) > 1 {
+		for _, leaf := range leaves {
+			exceptions = append(exceptions, er.newException(leaf, reflect.TypeOf(withoutWrappers(leaf)).String(), er.message(leaf), pcs))
+		}
+	}
 
 	event := map[string]interface{}{
 		"PayloadVersion": "2",
-		"exceptions": []*map[string]interface{}{
-			{
-				"errorClass": metadata.ErrorClass,
-				"message":    err.Error(),
-				"stacktrace": formatStack(stacktrace),
-			},
-		},
-		"severity": metadata.Severity,
-		"app": &map[string]interface{}{
-			"releaseStage": er.ReleaseStage,
-		},
-		"device": &map[string]interface{}{
-			"hostname": host,
-		},
+		"exceptions":     exceptions,
+		"severity":       metadata.Severity,
+		"unhandled":      metadata.Unhandled,
+		"app":            er.app(ctx),
+		"device":         er.device(at),
 	}
 
 	if "" != metadata.GroupingHash {
 		event["groupingHash"] = metadata.GroupingHash
+	} else if hash := er.frameGroupingHash(err, pcs); hash != "" {
+		event["groupingHash"] = hash
+	} else if er.GroupByNormalizedMessage {
+		normalizers := er.Normalizers
+		if normalizers == nil {
+			normalizers = DefaultNormalizers
+		}
+		event["groupingHash"] = NormalizeMessage(er.message(err), normalizers)
+	}
+	if metadata.User != nil {
+		event["user"] = metadata.User
+	}
+	if metadata.SeverityReason != nil {
+		event["severityReason"] = metadata.SeverityReason
 	}
 
-	if "" != metadata.Context {
-		event["context"] = metadata.Context
+	correlationID := CorrelationID(ctx)
+	if eventContext := er.eventContext(ctx, err, metadata); "" != eventContext {
+		event["context"] = eventContext
+	}
+	if "" == correlationID {
+		correlationID = newUUID()
 	}
 
+	metaData := map[string]interface{}{}
 	if !IsZeroInterface(metadata.EventMetadata) {
-		event["metaData"] = metadata.EventMetadata
+		for k, v := range *metadata.EventMetadata {
+			metaData[k] = resolveThunks(v)
+		}
+		// only the caller's tabs are bounded, the built-in ones below
+		// are small and must always arrive whole
+		if er.MaxMetadataDepth > 0 || er.MaxMetadataItems > 0 {
+			metaData = pruneMetadata(metaData, 0, er.MaxMetadataDepth, er.MaxMetadataItems).(map[string]interface{})
+		}
+	}
+
+	addTab(metaData, "event", map[string]interface{}{
+		"id": id,
+	})
+
+	errorTab := map[string]interface{}{
+		"occurredAt": occurredAt(err, at).UTC().Format(eventTimeFormat),
+		"reportedAt": at.UTC().Format(eventTimeFormat),
+	}
+	if er.CaptureVerbose && !hasStack(err) {
+		if verbose, ok := verboseMessage(err); ok {
+			errorTab["verbose"] = verbose
+		}
+	}
+	if h := hint(er.Hints, err); h != "" {
+		errorTab["hint"] = h
+	}
+	if truncateMessage(message, er.MaxMessageBytes) != message {
+		errorTab["fullMessage"] = message
+	}
+	addTab(metaData, "error", errorTab)
+
+	if len(metadata.Tags) > 0 {
+		tags := make(map[string]interface{}, len(metadata.Tags))
+		for k, v := range metadata.Tags {
+			tags[k] = v
+		}
+		addTab(metaData, "tags", tags)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		addTab(metaData, "context", map[string]interface{}{
+			"deadline":  deadline.Format(time.RFC3339Nano),
+			"remaining": time.Until(deadline).String(),
+		})
+	}
+
+	addTab(metaData, "correlation", map[string]interface{}{
+		"id": correlationID,
+	})
+
+	if er.Logs != nil {
+		if lines := er.Logs.Lines(); len(lines) > 0 {
+			addTab(metaData, "logs", map[string]interface{}{
+				"lines": lines,
+			})
+		}
+	}
+
+	if !er.DisableBuildInfo {
+		if tab := buildInfoTab(); tab != nil {
+			addTab(metaData, "build", tab)
+		}
+	}
+	if len(er.Dependencies) > 0 {
+		if tab := dependenciesTab(er.Dependencies); tab != nil {
+			addTab(metaData, "dependencies", tab)
+		}
+	}
+
+	if er.CompressMetadataOver > 0 {
+		metaData = compressMetadata(metaData, er.CompressMetadataOver)
+	}
+
+	if len(metaData) > 0 {
+		event["metaData"] = metaData
 	}
 
 	return &event
 }
 
-func IsZeroInterface(i interface{}) bool {
-	return i == reflect.Zero(reflect.TypeOf(i)).Interface()
+func (er *BugsnagReporter) eventContext(ctx context.Context, err error, metadata *BugsnagMetadata) string {
+	if "" != metadata.Context {
+		return metadata.Context
+	}
+	if er.ContextFunc != nil {
+		if c := er.ContextFunc(ctx, err); "" != c {
+			return c
+		}
+	}
+	return CorrelationID(ctx)
+}
+
+// releaseStage returns the release stage stored in ctx by
+// WithReleaseStage, defaulting to ReleaseStage
+func (er *BugsnagReporter) releaseStage(ctx context.Context) string {
+	if stage := ContextReleaseStage(ctx); stage != "" {
+		return stage
+	}
+	return er.ReleaseStage
 }
 
-func formatStack(s errors.StackTrace) []map[string]interface{} {
-	var o []map[string]interface{}
+func (er *BugsnagReporter) app(ctx context.Context) map[string]interface{} {
+	app := map[string]interface{}{
+		"releaseStage": er.releaseStage(ctx),
+	}
+	if "" != er.AppVersion {
+		app["version"] = er.AppVersion
+	}
+	return app
+}
 
-	for _, f := range s {
-		line, _ := strconv.Atoi(fmt.Sprintf("%d", f))
-		o = append(o, map[string]interface{}{
-			"method":     fmt.Sprintf("%n", f),
-			"file":       fmt.Sprintf("%s", f),
-			"lineNumber": line,
-		})
+func (er *BugsnagReporter) device(at time.Time) map[string]interface{} {
+	host, _ := os.Hostname()
+	device := map[string]interface{}{
+		"hostname": host,
+		"time":     at.UTC().Format(eventTimeFormat),
+	}
+	if id, ok := goroutineID(); ok {
+		device["threadId"] = id
 	}
 
-	return o
+	if er.DeviceInfo != nil {
+		for k, v := range er.DeviceInfo() {
+			device[k] = v
+		}
+	}
+
+	return device
+}
+
+// addTab merges tab into the metadata tab called name, leaving
+// any values already set by the caller untouched
+func addTab(metaData map[string]interface{}, name string, tab map[string]interface{}) {
+	existing, ok := metaData[name].(map[string]interface{})
+	if !ok {
+		if _, set := metaData[name]; !set {
+			metaData[name] = tab
+		}
+		return
+	}
+
+	merged := make(map[string]interface{}, len(existing)+len(tab))
+	for k, v := range tab {
+		merged[k] = v
+	}
+	for k, v := range existing {
+		merged[k] = v
+	}
+	metaData[name] = merged
+}
+
+func IsZeroInterface(i interface{}) bool {
+	return i == reflect.Zero(reflect.TypeOf(i)).Interface()
 }
@@ -1,14 +1,44 @@
 package bugsnack
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"reflect"
 	"runtime"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
 )
 
+const testAPIKey = "0123456789abcdef0123456789abcdef"
+
+// sentEvent decodes the first event of the i'th payload sent to doer
+func sentEvent(t *testing.T, doer *MockDoer, i int) map[string]interface{} {
+	t.Helper()
+	bodies := doer.Bodies()
+	if len(bodies) <= i {
+		t.Fatalf("expected at least %d payloads, got %d", i+1, len(bodies))
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(bodies[i], &payload); err != nil {
+		t.Fatal(err)
+	}
+	return payload["events"].([]interface{})[0].(map[string]interface{})
+}
+
 func TestErrorReporter(t *testing.T) {
 	if os.Getenv("BUGSNAG_TEST") != "T" {
 		t.Skip("not running bugsnag reporter test")
@@ -56,3 +86,1134 @@ func TestNestedErrorReporter(t *testing.T) {
 
 	er.Report(context.Background(), errors.New("bugsnag multireporter test"))
 }
+
+func TestContextDeadlineMetadata(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	er.Report(ctx, errors.New("deadline test"))
+	er.Report(context.Background(), errors.New("no deadline test"))
+
+	tab := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["context"].(map[string]interface{})
+	if _, err := time.Parse(time.RFC3339Nano, tab["deadline"].(string)); err != nil {
+		t.Errorf("expected an RFC3339 deadline, got %v", tab["deadline"])
+	}
+	remaining, err := time.ParseDuration(tab["remaining"].(string))
+	if err != nil || remaining <= 0 || remaining > time.Minute {
+		t.Errorf("expected remaining to be within the timeout, got %v", tab["remaining"])
+	}
+
+	if _, ok := sentEvent(t, doer, 1)["metaData"].(map[string]interface{})["context"]; ok {
+		t.Errorf("expected no context tab without a deadline")
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+
+	ctx := WithCorrelationID(context.Background(), "req-1234")
+	if id := CorrelationID(ctx); id != "req-1234" {
+		t.Fatalf("expected req-1234, got %q", id)
+	}
+
+	er.Report(ctx, errors.New("correlated"))
+	event := sentEvent(t, doer, 0)
+	if event["context"] != "req-1234" {
+		t.Errorf("expected event context req-1234, got %v", event["context"])
+	}
+	tab := event["metaData"].(map[string]interface{})["correlation"].(map[string]interface{})
+	if tab["id"] != "req-1234" {
+		t.Errorf("expected correlation id req-1234, got %v", tab["id"])
+	}
+
+	er.Report(context.Background(), errors.New("uncorrelated"))
+	er.Report(context.Background(), errors.New("uncorrelated"))
+	first := sentEvent(t, doer, 1)["metaData"].(map[string]interface{})["correlation"].(map[string]interface{})["id"]
+	second := sentEvent(t, doer, 2)["metaData"].(map[string]interface{})["correlation"].(map[string]interface{})["id"]
+	if first == "" || first == second {
+		t.Errorf("expected distinct generated ids, got %v and %v", first, second)
+	}
+	if _, ok := sentEvent(t, doer, 1)["context"]; ok {
+		t.Errorf("expected no event context for a generated id")
+	}
+
+	er.Report(ctx, errors.New("explicit context"), &BugsnagMetadata{Context: "fetchWorker"})
+	if c := sentEvent(t, doer, 3)["context"]; c != "fetchWorker" {
+		t.Errorf("expected explicit context to win, got %v", c)
+	}
+}
+
+func TestDeviceInfo(t *testing.T) {
+	doer := &MockDoer{}
+	calls := 0
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   doer,
+		DeviceInfo: CachedDeviceInfo(func() map[string]interface{} {
+			calls++
+			return map[string]interface{}{
+				"podName":   "api-7d9f8",
+				"namespace": "production",
+			}
+		}),
+	}
+
+	er.Report(context.Background(), errors.New("device test"))
+	er.Report(context.Background(), errors.New("device test"))
+
+	device := sentEvent(t, doer, 1)["device"].(map[string]interface{})
+	if device["podName"] != "api-7d9f8" || device["namespace"] != "production" {
+		t.Errorf("expected hook values in device, got %v", device)
+	}
+	if _, ok := device["hostname"]; !ok {
+		t.Errorf("expected default hostname to be kept, got %v", device)
+	}
+	if calls != 1 {
+		t.Errorf("expected cached hook to be called once, got %d", calls)
+	}
+}
+
+func TestNilDoer(t *testing.T) {
+	doer := &MockDoer{}
+	defer func(d Doer) { defaultDoer = d }(defaultDoer)
+	defaultDoer = doer
+
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: nil}
+	if err := er.ReportE(context.Background(), errors.New("nil doer test")); err != nil {
+		t.Fatalf("expected delivery through the default doer, got %v", err)
+	}
+	if len(doer.Requests()) != 1 {
+		t.Errorf("expected the default doer to be used, got %d payloads", len(doer.Requests()))
+	}
+}
+
+func originError() error {
+	return pkgerrors.New("origin")
+}
+
+func TestDeepestStackTrace(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+
+	er.Report(context.Background(), fmt.Errorf("outer: %w", originError()))
+	er.Report(context.Background(), errors.New("no stack"))
+
+	frames := func(i int) []interface{} {
+		exception := sentEvent(t, doer, i)["exceptions"].([]interface{})[0].(map[string]interface{})
+		return exception["stacktrace"].([]interface{})
+	}
+	if method := frames(0)[0].(map[string]interface{})["method"]; method != "originError" {
+		t.Errorf("expected the stack to start at originError, got %v", method)
+	}
+	if method := frames(1)[0].(map[string]interface{})["method"]; method != "TestDeepestStackTrace" {
+		t.Errorf("expected the stack to start at the caller of Report, got %v", method)
+	}
+}
+
+type routeKey struct{}
+
+func TestContextFunc(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   doer,
+		ContextFunc: func(ctx context.Context, _ error) string {
+			route, _ := ctx.Value(routeKey{}).(string)
+			return route
+		},
+	}
+
+	ctx := context.WithValue(context.Background(), routeKey{}, "GET /users/:id")
+	er.Report(ctx, errors.New("derived context"))
+	er.Report(ctx, errors.New("explicit context"), &BugsnagMetadata{Context: "fetchWorker"})
+	er.Report(WithCorrelationID(context.Background(), "req-1"), errors.New("nothing derived"))
+
+	if c := sentEvent(t, doer, 0)["context"]; c != "GET /users/:id" {
+		t.Errorf("expected derived context, got %v", c)
+	}
+	if c := sentEvent(t, doer, 1)["context"]; c != "fetchWorker" {
+		t.Errorf("expected explicit context to win, got %v", c)
+	}
+	if c := sentEvent(t, doer, 2)["context"]; c != "req-1" {
+		t.Errorf("expected correlation id when nothing is derived, got %v", c)
+	}
+}
+
+func TestMetadataPruning(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey:           testAPIKey,
+		Doer:             doer,
+		MaxMetadataDepth: 2,
+		MaxMetadataItems: 2,
+	}
+
+	er.Report(context.Background(), errors.New("pruning test"), &BugsnagMetadata{
+		EventMetadata: &map[string]interface{}{
+			"data": map[string]interface{}{
+				"level2": map[string]interface{}{
+					"level3": map[string]interface{}{
+						"level4": map[string]interface{}{"deep": "value"},
+					},
+				},
+				"list": []int{1, 2, 3, 4, 5},
+			},
+		},
+	})
+
+	data := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["data"].(map[string]interface{})
+	level3 := data["level2"].(map[string]interface{})["level3"]
+	if level3 != prunedMarker {
+		t.Errorf("expected level3 to be pruned, got %v", level3)
+	}
+	list := data["list"].([]interface{})
+	if len(list) != 3 || list[2] != "[pruned] 3 more items" {
+		t.Errorf("expected list to be cut to 2 items plus a marker, got %v", list)
+	}
+}
+
+func TestMetadataPruningSparesBuiltInTabs(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey:           testAPIKey,
+		Doer:             doer,
+		MaxMetadataItems: 1,
+	}
+
+	er.Report(context.Background(), errors.New("pruning test"), &BugsnagMetadata{
+		Tags: map[string]string{"region": "eu"},
+		EventMetadata: &map[string]interface{}{
+			"user": map[string]interface{}{"id": 42, "plan": "pro"},
+		},
+	})
+
+	metaData := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})
+	for _, tab := range []string{"event", "error", "tags", "correlation", "user"} {
+		if _, ok := metaData[tab]; !ok {
+			t.Errorf("expected the %q tab to be kept, got %v", tab, metaData)
+		}
+	}
+	if _, ok := metaData[prunedMarker]; ok {
+		t.Errorf("expected no tabs to be pruned, got %v", metaData)
+	}
+	if errorTab := metaData["error"].(map[string]interface{}); len(errorTab) < 2 {
+		t.Errorf("expected the built-in error tab to be whole, got %v", errorTab)
+	}
+	user := metaData["user"].(map[string]interface{})
+	if len(user) != 2 || user["id"] != float64(42) || user[prunedMarker] != "1 more items" {
+		t.Errorf("expected the user tab to be cut to 1 item plus a marker, got %v", user)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	defer func(f func() (*debug.BuildInfo, bool)) { readBuildInfo = f }(readBuildInfo)
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Deps: []*debug.Module{
+				{Path: "github.com/lib/pq", Version: "v1.10.9"},
+				{Path: "github.com/lib/pqx", Version: "v0.1.0"},
+				{Path: "github.com/aws/aws-sdk-go-v2", Version: "v1.21.0"},
+				{Path: "github.com/aws/smithy-go", Version: "v1.14.2", Replace: &debug.Module{Path: "../smithy-go"}},
+				{Path: "github.com/pkg/errors", Version: "v0.9.1"},
+			},
+		}, true
+	}
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Dependencies: []string{"github.com/lib/pq", "github.com/aws/", "example.com/missing"}}
+	er.Report(context.Background(), errors.New("dependencies test"))
+
+	want := map[string]interface{}{
+		"github.com/lib/pq":            "v1.10.9",
+		"github.com/aws/aws-sdk-go-v2": "v1.21.0",
+		"github.com/aws/smithy-go":     "=> ../smithy-go",
+	}
+	if got := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["dependencies"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected dependencies %v, got %v", want, got)
+	}
+
+	er.Dependencies = []string{"example.com/missing"}
+	er.Report(context.Background(), errors.New("dependencies test"))
+	if _, ok := sentEvent(t, doer, 1)["metaData"].(map[string]interface{})["dependencies"]; ok {
+		t.Errorf("expected no dependencies tab without matching modules")
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	defer func(f func() (*debug.BuildInfo, bool)) { readBuildInfo = f }(readBuildInfo)
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.20",
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "99c9e86"},
+				{Key: "vcs.modified", Value: "true"},
+				{Key: "vcs.time", Value: "2017-05-05T04:36:39Z"},
+			},
+		}, true
+	}
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), errors.New("build info test"))
+
+	tab := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["build"].(map[string]interface{})
+	if tab["revision"] != "99c9e86" || tab["modified"] != true || tab["time"] != "2017-05-05T04:36:39Z" {
+		t.Errorf("unexpected build tab %v", tab)
+	}
+
+	er.DisableBuildInfo = true
+	er.Report(context.Background(), errors.New("build info test"))
+	if _, ok := sentEvent(t, doer, 1)["metaData"].(map[string]interface{})["build"]; ok {
+		t.Errorf("expected no build tab when disabled")
+	}
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	er.DisableBuildInfo = false
+	er.Report(context.Background(), errors.New("build info test"))
+	if _, ok := sentEvent(t, doer, 2)["metaData"].(map[string]interface{})["build"]; ok {
+		t.Errorf("expected no build tab without build info")
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	key := []byte("secret")
+	sign := func(body []byte) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   doer,
+		SignRequest: func(body []byte) (string, string) {
+			return "X-Signature", sign(body)
+		},
+	}
+
+	if err := er.ReportE(context.Background(), errors.New("signing test")); err != nil {
+		t.Fatal(err)
+	}
+	sent := doer.Bodies()[0]
+	signature := doer.Requests()[0].Header.Get("X-Signature")
+	if len(sent) == 0 || signature != sign(sent) {
+		t.Errorf("expected signature over the sent body, got %q", signature)
+	}
+}
+
+func TestNotifyReleaseStages(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey:              testAPIKey,
+		Doer:                doer,
+		ReleaseStage:        "production",
+		NotifyReleaseStages: []string{"production", "staging"},
+	}
+
+	er.Report(context.Background(), errors.New("allowed stage"))
+	if len(doer.Requests()) != 1 {
+		t.Fatalf("expected production to be reported, got %d payloads", len(doer.Requests()))
+	}
+
+	er.ReleaseStage = "development"
+	er.Report(context.Background(), errors.New("disallowed stage"))
+	if len(doer.Requests()) != 1 {
+		t.Errorf("expected development to be suppressed, got %d payloads", len(doer.Requests()))
+	}
+}
+
+func TestRequestBuilder(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   doer,
+		RequestBuilder: func(ctx context.Context, body []byte) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPut, "http://gateway.internal/errors/bugsnag", bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("X-Gateway-Route", "bugsnag")
+			return req, nil
+		},
+	}
+
+	if err := er.ReportE(context.Background(), errors.New("request builder test")); err != nil {
+		t.Fatal(err)
+	}
+	got := doer.Requests()[0]
+	if got.Method != http.MethodPut || got.URL.String() != "http://gateway.internal/errors/bugsnag" {
+		t.Errorf("expected the custom request, got %s %s", got.Method, got.URL)
+	}
+	if got.Header.Get("X-Gateway-Route") != "bugsnag" {
+		t.Errorf("expected the custom header, got %v", got.Header)
+	}
+
+	er.RequestBuilder = nil
+	if err := er.ReportE(context.Background(), errors.New("request builder test")); err != nil {
+		t.Fatal(err)
+	}
+	got = doer.Requests()[1]
+	if got.Method != http.MethodPost || got.URL.String() != defaultEndpoint {
+		t.Errorf("expected the default request, got %s %s", got.Method, got.URL)
+	}
+}
+
+func TestUnencodableMetadata(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+
+	err := er.ReportE(context.Background(), errors.New("encoding test"), &BugsnagMetadata{
+		EventMetadata: &map[string]interface{}{
+			"data": map[string]interface{}{
+				"callback": func() {},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected the event to be delivered, got %v", err)
+	}
+
+	event := sentEvent(t, doer, 0)
+	exception := event["exceptions"].([]interface{})[0].(map[string]interface{})
+	if exception["message"] != "encoding test" {
+		t.Errorf("expected the error to be delivered, got %v", exception["message"])
+	}
+	metaData := event["metaData"].(map[string]interface{})
+	if _, ok := metaData["data"]; ok {
+		t.Errorf("expected the metadata to be dropped, got %v", metaData)
+	}
+	if note := metaData["bugsnack"].(map[string]interface{})["metaDataDropped"]; note == "" || note == nil {
+		t.Errorf("expected a note that the metadata was dropped, got %v", metaData)
+	}
+}
+
+func TestLazyMetadata(t *testing.T) {
+	doer := &MockDoer{}
+	calls := 0
+	expensive := func() interface{} {
+		calls++
+		return map[string]interface{}{"rows": 42}
+	}
+	metadata := func() *BugsnagMetadata {
+		return &BugsnagMetadata{EventMetadata: &map[string]interface{}{
+			"query": map[string]interface{}{"plan": expensive},
+		}}
+	}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, ReleaseStage: "production"}
+	ctx := context.Background()
+
+	sampled := &SamplingReporter{Reporter: er, Rates: map[string]float64{"error": 0}}
+	sampled.Report(ctx, errors.New("sampled out"), metadata())
+	er.NotifyReleaseStages = []string{"production"}
+	er.Report(WithReleaseStage(ctx, "development"), errors.New("filtered"), metadata())
+	if calls != 0 || len(doer.Requests()) != 0 {
+		t.Fatalf("expected filtered events not to compute their metadata, got %d calls", calls)
+	}
+
+	er.Report(ctx, errors.New("sent"), metadata())
+	if calls != 1 {
+		t.Errorf("expected the thunk to be called once, got %d calls", calls)
+	}
+	query := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["query"].(map[string]interface{})
+	if rows := query["plan"].(map[string]interface{})["rows"]; rows != 42.0 {
+		t.Errorf("expected the thunk's result to be sent, got %v", query)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	ctx := context.Background()
+
+	er.Report(ctx, WithErrorCode(errors.New("connection refused"), "ERR_DB_CONN_1001"))
+	er.ErrorCodeInMessage = true
+	er.Report(ctx, WithErrorCode(errors.New("connection refused"), "ERR_DB_CONN_1001"), NewMetadata().Code("ERR_DB_CONN_1002").Build())
+	er.Report(ctx, errors.New("no code"))
+
+	for i, want := range []struct{ code, message string }{
+		{"ERR_DB_CONN_1001", "connection refused"},
+		{"ERR_DB_CONN_1002", "[ERR_DB_CONN_1002] connection refused"},
+		{"", "no code"},
+	} {
+		event := sentEvent(t, doer, i)
+		exception := event["exceptions"].([]interface{})[0].(map[string]interface{})
+		if exception["message"] != want.message {
+			t.Errorf("report %d: expected message %q, got %q", i, want.message, exception["message"])
+		}
+		tags, _ := event["metaData"].(map[string]interface{})["tags"].(map[string]interface{})
+		if code, _ := tags["error_code"].(string); code != want.code {
+			t.Errorf("report %d: expected error_code tag %q, got %v", i, want.code, tags)
+		}
+	}
+}
+
+// textStackError renders a stack with %+v, but offers no structured
+// access to it
+type textStackError struct{}
+
+func (textStackError) Error() string { return "legacy failure" }
+
+func (e textStackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		io.WriteString(s, "legacy failure\nlegacy.Do\n\tlegacy.go:42")
+		return
+	}
+	io.WriteString(s, e.Error())
+}
+
+func TestCaptureVerbose(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	ctx := context.Background()
+
+	er.Report(ctx, textStackError{})
+	er.CaptureVerbose = true
+	er.Report(ctx, fmt.Errorf("calling legacy: %w", textStackError{}))
+	er.Report(ctx, pkgerrors.New("has a stack"))
+	er.Report(ctx, errors.New("no formatter"))
+
+	for i, want := range []string{"", "legacy failure\nlegacy.Do\n\tlegacy.go:42", "", ""} {
+		tab := sentEvent(t, doer, i)["metaData"].(map[string]interface{})["error"].(map[string]interface{})
+		if verbose, _ := tab["verbose"].(string); verbose != want {
+			t.Errorf("report %d: expected verbose %q, got %q", i, want, verbose)
+		}
+	}
+}
+
+type panickyValue struct{}
+
+func (panickyValue) MarshalJSON() ([]byte, error) {
+	panic("no JSON for you")
+}
+
+func TestPanickingMetadata(t *testing.T) {
+	doer := &MockDoer{}
+	backup := &recordingReporter{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Backup: backup}
+
+	err := er.ReportE(context.Background(), errors.New("encoding test"), &BugsnagMetadata{
+		EventMetadata: &map[string]interface{}{
+			"data": map[string]interface{}{"value": panickyValue{}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected the event to be delivered, got %v", err)
+	}
+
+	event := sentEvent(t, doer, 0)
+	metaData := event["metaData"].(map[string]interface{})
+	if _, ok := metaData["data"]; ok {
+		t.Errorf("expected the metadata to be dropped, got %v", metaData)
+	}
+	note, _ := metaData["bugsnack"].(map[string]interface{})["metaDataDropped"].(string)
+	if !strings.Contains(note, "no JSON for you") {
+		t.Errorf("expected a note naming the panic, got %v", metaData)
+	}
+	if len(backup.errs) != 1 || !strings.Contains(backup.errs[0].Error(), "panic encoding payload") {
+		t.Errorf("expected the panic to be reported to the backup, got %v", backup.errs)
+	}
+}
+
+func TestResponseReadLimit(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	doer := (&MockDoer{}).RespondWith(http.StatusBadRequest, body)
+
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	err := er.ReportE(context.Background(), errors.New("read limit test"))
+	if err == nil || strings.Count(err.Error(), "x") != 1024 {
+		t.Errorf("expected 1024 bytes of the body by default, got %d", strings.Count(fmt.Sprint(err), "x"))
+	}
+
+	er.ResponseReadLimit = 3000
+	err = er.ReportE(context.Background(), errors.New("read limit test"))
+	if err == nil || strings.Count(err.Error(), "x") != 3000 {
+		t.Errorf("expected 3000 bytes of the body, got %d", strings.Count(fmt.Sprint(err), "x"))
+	}
+}
+
+var errValidation = errors.New("validation failed")
+
+func TestClassifier(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   doer,
+		Classifier: func(err error) (string, string) {
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				return "warning", "timeout"
+			case errors.Is(err, sql.ErrNoRows):
+				return "error", "sql"
+			case errors.Is(err, errValidation):
+				return "info", ""
+			}
+			return "", ""
+		},
+	}
+	ctx := context.Background()
+
+	er.Report(ctx, fmt.Errorf("fetching user: %w", context.DeadlineExceeded))
+	er.Report(ctx, sql.ErrNoRows)
+	er.Report(ctx, errValidation)
+	er.Report(ctx, errors.New("unclassified"))
+	er.Report(ctx, context.DeadlineExceeded, &BugsnagMetadata{Severity: "error"})
+
+	for i, want := range []struct{ severity, class string }{
+		{"warning", "timeout"},
+		{"error", "sql"},
+		{"info", "*errors.errorString"},
+		{"error", "*errors.errorString"},
+		{"error", "timeout"},
+	} {
+		event := sentEvent(t, doer, i)
+		class := event["exceptions"].([]interface{})[0].(map[string]interface{})["errorClass"]
+		if event["severity"] != want.severity || class != want.class {
+			t.Errorf("event %d: expected %s/%s, got %v/%v", i, want.severity, want.class, event["severity"], class)
+		}
+	}
+}
+
+func TestStackNotDuplicated(t *testing.T) {
+	doer := &MockDoer{}
+	er := WithDefaults(&BugsnagReporter{APIKey: testAPIKey, Doer: doer}, &BugsnagMetadata{Context: "decorated"})
+
+	er.Report(context.Background(), originError())
+	er.Report(context.Background(), errors.New("no stack"))
+
+	for i, want := range []string{"originError", "TestStackNotDuplicated"} {
+		exception := sentEvent(t, doer, i)["exceptions"].([]interface{})[0].(map[string]interface{})
+		frames := exception["stacktrace"].([]interface{})
+		top := frames[0].(map[string]interface{})["method"]
+		next := frames[1].(map[string]interface{})["method"]
+		if top != want || next == top {
+			t.Errorf("expected the stack to start once at %s, got %v then %v", want, top, next)
+		}
+	}
+}
+
+type discardDoer struct{}
+
+func (discardDoer) Do(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func BenchmarkReportWithStack(b *testing.B) {
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: discardDoer{}, DisableBuildInfo: true}
+	err := originError()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		er.Report(ctx, err)
+	}
+}
+
+func TestMessageFunc(t *testing.T) {
+	err := fmt.Errorf("loading user 42: %w", fmt.Errorf("querying db: %w", errors.New("connection refused")))
+	message := func(doer *MockDoer) interface{} {
+		return sentEvent(t, doer, 0)["exceptions"].([]interface{})[0].(map[string]interface{})["message"]
+	}
+
+	doer := &MockDoer{}
+	(&BugsnagReporter{APIKey: testAPIKey, Doer: doer}).Report(context.Background(), err)
+	if m := message(doer); m != err.Error() {
+		t.Errorf("expected err.Error() by default, got %v", m)
+	}
+
+	doer = &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   doer,
+		MessageFunc: func(err error) string {
+			return strings.SplitN(err.Error(), ":", 2)[0]
+		},
+	}
+	er.Report(context.Background(), err)
+	if m := message(doer); m != "loading user 42" {
+		t.Errorf("expected the custom message, got %v", m)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	doer := &MockDoer{}
+	doer.RespondWith(http.StatusOK, "")
+	doer.RespondWith(http.StatusInternalServerError, "")
+	archive := &recordingReporter{}
+	backup := &recordingReporter{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Archive: archive, Backup: backup}
+
+	er.Report(context.Background(), errors.New("delivered"), &BugsnagMetadata{Severity: "warning"})
+	er.Report(context.Background(), errors.New("undelivered"))
+
+	if len(archive.errs) != 2 || archive.errs[0].Error() != "delivered" || archive.errs[1].Error() != "undelivered" {
+		t.Fatalf("expected both reports to be archived, got %v", archive.errs)
+	}
+	if archive.metadata[0].Severity != "warning" {
+		t.Errorf("expected the metadata to be archived, got %+v", archive.metadata[0])
+	}
+	if len(backup.errs) != 1 {
+		t.Errorf("expected only the failed report to reach Backup, got %v", backup.errs)
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	doer := &MockDoer{}
+	doer.RespondWith(http.StatusServiceUnavailable, "")
+	doer.RespondWith(http.StatusOK, "")
+	er := &BugsnagReporter{
+		APIKey:       testAPIKey,
+		Doer:         doer,
+		MaxRetries:   1,
+		RetryBackoff: time.Millisecond,
+		RequestBuilder: func(ctx context.Context, body []byte) (*http.Request, error) {
+			// delivery happens later than the report
+			clock = clock.Add(time.Hour)
+			return http.NewRequestWithContext(ctx, http.MethodPost, defaultEndpoint, bytes.NewReader(body))
+		},
+	}
+	er.Report(context.Background(), errors.New("delayed delivery"))
+
+	for i := 0; i < 2; i++ {
+		device := sentEvent(t, doer, i)["device"].(map[string]interface{})
+		if device["time"] != "2017-05-05T10:00:00.000Z" {
+			t.Errorf("expected the time of the report, got %v", device["time"])
+		}
+	}
+}
+
+func TestWithReportMetadata(t *testing.T) {
+	base := errors.New("connection refused")
+	err := WithReportMetadata(base, &BugsnagMetadata{
+		Context:  "db",
+		Severity: "info",
+		EventMetadata: &map[string]interface{}{
+			"query": map[string]interface{}{"table": "users", "op": "select"},
+		},
+	})
+	err = fmt.Errorf("loading user: %w", err)
+	err = WithReportMetadata(err, &BugsnagMetadata{
+		Severity:      "warning",
+		EventMetadata: &map[string]interface{}{"query": map[string]interface{}{"op": "update"}},
+	})
+
+	if WithReportMetadata(nil, &BugsnagMetadata{}) != nil {
+		t.Error("expected nil errors to stay nil")
+	}
+	if !errors.Is(err, base) || err.Error() != "loading user: connection refused" {
+		t.Errorf("expected the wrapped error to be unchanged, got %v", err)
+	}
+	attached := errorMetadata(err)
+	if attached.Context != "db" || attached.Severity != "warning" {
+		t.Errorf("expected the outermost metadata to win, got %+v", attached)
+	}
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), err, &BugsnagMetadata{Severity: "error"})
+
+	event := sentEvent(t, doer, 0)
+	if event["severity"] != "error" || event["context"] != "db" {
+		t.Errorf("expected the call site metadata to win, got %v / %v", event["severity"], event["context"])
+	}
+	query := event["metaData"].(map[string]interface{})["query"].(map[string]interface{})
+	if query["table"] != "users" || query["op"] != "update" {
+		t.Errorf("unexpected merged metadata %v", query)
+	}
+	exception := event["exceptions"].([]interface{})[0].(map[string]interface{})
+	if exception["errorClass"] != "*fmt.wrapError" {
+		t.Errorf("expected the wrapper not to be the error class, got %v", exception["errorClass"])
+	}
+}
+
+func TestTags(t *testing.T) {
+	ctx := WithTags(context.Background(), map[string]string{"region": "eu", "tenant": "acme"})
+	ctx = WithTags(ctx, map[string]string{"tenant": "globex", "route": "/users"})
+
+	doer := &MockDoer{}
+	er := WithDefaults(&BugsnagReporter{APIKey: testAPIKey, Doer: doer}, &BugsnagMetadata{
+		Tags: map[string]string{"service": "api", "region": "us"},
+	})
+	er.Report(ctx, errors.New("tagged"), &BugsnagMetadata{Tags: map[string]string{"route": "/users/:id"}})
+
+	tags := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["tags"].(map[string]interface{})
+	want := map[string]interface{}{
+		"region":  "eu",
+		"tenant":  "globex",
+		"route":   "/users/:id",
+		"service": "api",
+	}
+	if len(tags) != len(want) {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+	for k, v := range want {
+		if tags[k] != v {
+			t.Errorf("expected tag %s to be %v, got %v", k, v, tags[k])
+		}
+	}
+}
+
+func TestMisconfiguredWritesToStderr(t *testing.T) {
+	var out bytes.Buffer
+	defer func(w io.Writer) { stderr = w }(stderr)
+	stderr = &out
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{Doer: doer}
+	er.Report(context.Background(), errors.New("first"))
+	er.Report(context.Background(), errors.New("second"))
+
+	want := ErrMisconfigured.Error() + ", writing errors to stderr\nfirst\nsecond\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+	if len(doer.Requests()) != 0 {
+		t.Errorf("expected nothing to be sent, got %d requests", len(doer.Requests()))
+	}
+	if err := er.ReportE(context.Background(), errors.New("third")); err != ErrMisconfigured {
+		t.Errorf("expected ErrMisconfigured, got %v", err)
+	}
+}
+
+func TestMaxTotalReports(t *testing.T) {
+	var out bytes.Buffer
+	defer func(w io.Writer) { stderr = w }(stderr)
+	stderr = &out
+
+	doer := &MockDoer{}
+	backup := &recordingReporter{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Backup: backup, MaxTotalReports: 3}
+	for i := 0; i < 10; i++ {
+		er.Report(context.Background(), fmt.Errorf("runaway %d", i))
+	}
+
+	if n := len(doer.Requests()); n != 3 {
+		t.Errorf("expected reporting to stop after 3 reports, got %d", n)
+	}
+	if want := ErrReportLimit.Error() + "\n"; out.String() != want {
+		t.Errorf("expected a single notice %q, got %q", want, out.String())
+	}
+	if len(backup.errs) != 0 {
+		t.Errorf("expected dropped errors not to reach the backup, got %v", backup.errs)
+	}
+	if err := er.ReportE(context.Background(), errors.New("more")); err != ErrReportLimit {
+		t.Errorf("expected ErrReportLimit, got %v", err)
+	}
+}
+
+func TestCoalesceWindow(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, CoalesceWindow: 100 * time.Millisecond}
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		er.Report(ctx, errors.New("connection refused"))
+		clock = clock.Add(10 * time.Millisecond)
+	}
+	er.Report(ctx, errors.New("giving up"))
+	clock = clock.Add(time.Second)
+	er.Report(ctx, errors.New("giving up"))
+
+	if n := len(doer.Requests()); n != 3 {
+		t.Fatalf("expected repeats to be folded into 3 sends, got %d", n)
+	}
+	if _, ok := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["coalesced"]; ok {
+		t.Errorf("expected no count on the first report")
+	}
+	tab := sentEvent(t, doer, 1)["metaData"].(map[string]interface{})["coalesced"].(map[string]interface{})
+	if tab["count"] != 49.0 || tab["group"] != "connection refused" {
+		t.Errorf("expected the next distinct report to carry the count, got %v", tab)
+	}
+	if _, ok := sentEvent(t, doer, 2)["metaData"].(map[string]interface{})["coalesced"]; ok {
+		t.Errorf("expected a report after the window to be sent without a count")
+	}
+}
+
+func TestCoalesceWindowWithMaxTotalReports(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	doer := &MockDoer{}
+	archive := &recordingReporter{}
+	er := &BugsnagReporter{
+		APIKey:          testAPIKey,
+		Doer:            doer,
+		Archive:         archive,
+		CoalesceWindow:  100 * time.Millisecond,
+		MaxTotalReports: 2,
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if err := er.ReportE(ctx, errors.New("connection refused")); err != nil {
+			t.Fatalf("expected repeats not to count against the limit, got %v", err)
+		}
+		clock = clock.Add(10 * time.Millisecond)
+	}
+	if err := er.ReportE(ctx, errors.New("giving up")); err != nil {
+		t.Fatalf("expected the second distinct error to be sent, got %v", err)
+	}
+
+	if n := len(doer.Requests()); n != 2 {
+		t.Fatalf("expected 2 sends, got %d", n)
+	}
+	if len(archive.errs) != 2 {
+		t.Errorf("expected only reports which weren't folded to be archived, got %d", len(archive.errs))
+	}
+	tab := sentEvent(t, doer, 1)["metaData"].(map[string]interface{})["coalesced"].(map[string]interface{})
+	if tab["count"] != 9.0 {
+		t.Errorf("expected the count of repeats, got %v", tab)
+	}
+}
+
+func TestNilBackupWritesToStderr(t *testing.T) {
+	var out bytes.Buffer
+	defer func(w io.Writer) { stderr = w }(stderr)
+	stderr = &out
+
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: (&MockDoer{}).FailWith(errors.New("connection refused"))}
+	er.Report(context.Background(), errors.New("undelivered"))
+
+	if !strings.Contains(out.String(), "connection refused") {
+		t.Errorf("expected the delivery failure on stderr, got %q", out.String())
+	}
+}
+
+func TestPrivate(t *testing.T) {
+	f, err := os.Create(t.TempDir() + "/errors.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doer := &MockDoer{}
+	archive := &recordingReporter{}
+	webhook := &MockDoer{}
+	er := &MultiReporter{Reporters: []ErrorReporter{
+		&BugsnagReporter{APIKey: testAPIKey, Doer: doer, Archive: archive},
+		&WebhookReporter{Doer: webhook, Template: "{{.Message}}"},
+		&WriterReporter{Writer: f},
+	}}
+
+	er.Report(context.Background(), errors.New("card 4111 declined"), &BugsnagMetadata{Private: true})
+	er.Report(context.Background(), WithPrivate(errors.New("ssn 078-05-1120 invalid")))
+	er.Report(context.Background(), errors.New("public"))
+
+	if len(doer.Requests()) != 1 || len(webhook.Requests()) != 1 {
+		t.Errorf("expected only the public error to be sent, got %d and %d", len(doer.Requests()), len(webhook.Requests()))
+	}
+	if len(archive.errs) != 3 {
+		t.Errorf("expected every error to be archived, got %d", len(archive.errs))
+	}
+	logged, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "card 4111 declined\nssn 078-05-1120 invalid\npublic\n"; string(logged) != want {
+		t.Errorf("expected %q in the file, got %q", want, logged)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), errors.New("user agent test"))
+	er.UserAgent = "billing-service/2.1"
+	er.Report(context.Background(), errors.New("user agent test"))
+
+	if ua := doer.Requests()[0].Header.Get("User-Agent"); ua != "Bugsnack/"+Version {
+		t.Errorf("expected the default user agent, got %q", ua)
+	}
+	if ua := doer.Requests()[1].Header.Get("User-Agent"); ua != "billing-service/2.1" {
+		t.Errorf("expected the configured user agent, got %q", ua)
+	}
+
+	var payload struct {
+		Notifier struct{ Version string }
+	}
+	if err := json.Unmarshal(doer.Bodies()[0], &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Notifier.Version != Version {
+		t.Errorf("expected notifier version %s, got %q", Version, payload.Notifier.Version)
+	}
+}
+
+func TestJoinedErrors(t *testing.T) {
+	notFound := pkgerrors.New("user 1 not found")
+	timeout := fmt.Errorf("user 2: %w", context.DeadlineExceeded)
+	invalid := errors.New("user 3 is invalid")
+	err := fmt.Errorf("batch failed: %w", errors.Join(notFound, errors.Join(timeout, invalid)))
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), err)
+
+	exceptions := sentEvent(t, doer, 0)["exceptions"].([]interface{})
+	if len(exceptions) != 4 {
+		t.Fatalf("expected the joined error and its 3 causes, got %d exceptions", len(exceptions))
+	}
+	for i, want := range []struct{ class, message string }{
+		{"*fmt.wrapError", err.Error()},
+		{"*errors.fundamental", "user 1 not found"},
+		{"*fmt.wrapError", "user 2: context deadline exceeded"},
+		{"*errors.errorString", "user 3 is invalid"},
+	} {
+		exception := exceptions[i].(map[string]interface{})
+		if exception["errorClass"] != want.class || exception["message"] != want.message {
+			t.Errorf("exception %d: expected %s %q, got %v %q", i, want.class, want.message, exception["errorClass"], exception["message"])
+		}
+	}
+	top := exceptions[1].(map[string]interface{})["stacktrace"].([]interface{})[0].(map[string]interface{})
+	if top["method"] != "TestJoinedErrors" {
+		t.Errorf("expected each cause to keep its own stack, got %v", top)
+	}
+
+	doer = &MockDoer{}
+	er = &BugsnagReporter{APIKey: testAPIKey, Doer: doer, SplitJoinedErrors: true}
+	er.Report(context.Background(), err, &BugsnagMetadata{Context: "batch"})
+
+	var payload struct {
+		Events []struct {
+			Context    string
+			Exceptions []struct{ ErrorClass, Message string }
+		}
+	}
+	if err := json.Unmarshal(doer.Bodies()[0], &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Events) != 3 {
+		t.Fatalf("expected an event per cause, got %d", len(payload.Events))
+	}
+	for i, message := range []string{"user 1 not found", "user 2: context deadline exceeded", "user 3 is invalid"} {
+		event := payload.Events[i]
+		if len(event.Exceptions) != 1 || event.Exceptions[0].Message != message || event.Context != "batch" {
+			t.Errorf("event %d: unexpected %+v", i, event)
+		}
+	}
+}
+
+func TestWithReleaseStage(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey:              testAPIKey,
+		Doer:                doer,
+		ReleaseStage:        "production",
+		NotifyReleaseStages: []string{"production", "staging"},
+	}
+
+	er.Report(WithReleaseStage(context.Background(), "staging"), errors.New("staging tenant"))
+	er.Report(WithReleaseStage(context.Background(), "test"), errors.New("test harness"))
+	er.Report(context.Background(), errors.New("default stage"))
+
+	if len(doer.Requests()) != 2 {
+		t.Fatalf("expected the context stage to decide what is sent, got %d payloads", len(doer.Requests()))
+	}
+	for i, want := range []string{"staging", "production"} {
+		app := sentEvent(t, doer, i)["app"].(map[string]interface{})
+		if app["releaseStage"] != want {
+			t.Errorf("expected release stage %s, got %v", want, app["releaseStage"])
+		}
+	}
+}
+
+func TestSkipCanceled(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, SkipCanceled: true}
+	ctx := context.Background()
+
+	er.Report(ctx, context.Canceled)
+	er.Report(ctx, context.DeadlineExceeded)
+	er.Report(ctx, fmt.Errorf("fetching user: %w", context.Canceled))
+	er.Report(ctx, pkgerrors.Wrap(context.DeadlineExceeded, "querying db"))
+	if n := len(doer.Requests()); n != 0 {
+		t.Errorf("expected cancellations to be skipped, got %d payloads", n)
+	}
+
+	er.Report(ctx, errors.New("real failure"))
+	er.SkipCanceled = false
+	er.Report(ctx, context.Canceled)
+	if n := len(doer.Requests()); n != 2 {
+		t.Errorf("expected other errors and opted-in cancellations to be sent, got %d payloads", n)
+	}
+}
+
+func TestEventID(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+
+	seen := map[string]bool{}
+	for i := 0; i < 10; i++ {
+		id, err := er.ReportWithID(context.Background(), errors.New("event id test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != 36 || seen[id] {
+			t.Fatalf("expected a new UUID per report, got %q", id)
+		}
+		seen[id] = true
+
+		tab := sentEvent(t, doer, i)["metaData"].(map[string]interface{})["event"].(map[string]interface{})
+		if tab["id"] != id {
+			t.Errorf("expected the returned ID %s in the payload, got %v", id, tab["id"])
+		}
+	}
+
+	er.NotifyReleaseStages = []string{"production"}
+	if id, err := er.ReportWithID(context.Background(), errors.New("not sent")); id != "" || err != nil {
+		t.Errorf("expected no ID when nothing is sent, got %q (%v)", id, err)
+	}
+}
+
+func TestMaxMessageBytes(t *testing.T) {
+	message := "Größenüberschreitung: 日本語のメッセージ"
+	for _, tc := range []struct {
+		max  int
+		want string
+	}{
+		{0, message},
+		{len(message), message},
+		{10, "Größe…"},
+		{12, "Größen…"},
+		{27, "Größenüberschreitung:…"},
+		{30, "Größenüberschreitung: …"},
+		{31, "Größenüberschreitung: 日…"},
+		{3, "…"},
+		{2, "Gr"},
+		{1, "G"},
+		{4, "G…"},
+	} {
+		if got := truncateMessage(message, tc.max); got != tc.want {
+			t.Errorf("max %d: expected %q, got %q", tc.max, tc.want, got)
+		}
+	}
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, MaxMessageBytes: 31}
+	er.Report(context.Background(), errors.New(message))
+	er.Report(context.Background(), errors.New("short"))
+
+	event := sentEvent(t, doer, 0)
+	exception := event["exceptions"].([]interface{})[0].(map[string]interface{})
+	if exception["message"] != "Größenüberschreitung: 日…" {
+		t.Errorf("unexpected message %q", exception["message"])
+	}
+	if full := event["metaData"].(map[string]interface{})["error"].(map[string]interface{})["fullMessage"]; full != message {
+		t.Errorf("expected the full message in the error tab, got %v", full)
+	}
+	if _, ok := sentEvent(t, doer, 1)["metaData"].(map[string]interface{})["error"].(map[string]interface{})["fullMessage"]; ok {
+		t.Error("expected no full message when nothing was cut")
+	}
+}
@@ -0,0 +1,74 @@
+package bugsnack
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// readBuildInfo is replaced in tests
+var readBuildInfo = debug.ReadBuildInfo
+
+// buildInfoTab returns the VCS revision, dirty flag and commit time
+// embedded by the go tool, or nil if they are not available
+func buildInfoTab() map[string]interface{} {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	tab := map[string]interface{}{}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			tab["revision"] = setting.Value
+		case "vcs.modified":
+			tab["modified"] = setting.Value == "true"
+		case "vcs.time":
+			tab["time"] = setting.Value
+		}
+	}
+	if len(tab) == 0 {
+		return nil
+	}
+
+	tab["goVersion"] = info.GoVersion
+	return tab
+}
+
+// dependenciesTab returns the versions of the modules the binary was
+// built with whose paths are in allow, or below one of them, or nil if
+// there are none. Replaced modules report their replacement.
+func dependenciesTab(allow []string) map[string]interface{} {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	tab := map[string]interface{}{}
+	for _, dep := range info.Deps {
+		if !allowedModule(dep.Path, allow) {
+			continue
+		}
+		version := dep.Version
+		if dep.Replace != nil {
+			version = "=> " + dep.Replace.Path
+			if dep.Replace.Version != "" {
+				version += " " + dep.Replace.Version
+			}
+		}
+		tab[dep.Path] = version
+	}
+	if len(tab) == 0 {
+		return nil
+	}
+	return tab
+}
+
+func allowedModule(path string, allow []string) bool {
+	for _, prefix := range allow {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// Command bugsnack-sidecar forwards newline-delimited JSON error
+// records (see bugsnack.Record) from stdin or a Unix socket to bugsnag,
+// so that processes not written in Go can report errors.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/fromatob/bugsnack"
+)
+
+func main() {
+	socket := flag.String("socket", "", "listen on this Unix socket instead of reading stdin")
+	apiKey := flag.String("api-key", os.Getenv("BUGSNAG_API_KEY"), "bugsnag API key")
+	releaseStage := flag.String("release-stage", os.Getenv("BUGSNAG_RELEASE_STAGE"), "bugsnag release stage")
+	flag.Parse()
+
+	backup := &bugsnack.WriterReporter{Writer: os.Stderr}
+	var er bugsnack.ErrorReporter = backup
+	if *apiKey != "" {
+		er = &bugsnack.BugsnagReporter{
+			APIKey:       *apiKey,
+			ReleaseStage: *releaseStage,
+			Doer:         http.DefaultClient,
+			Backup:       backup,
+		}
+	}
+
+	ctx := context.Background()
+	if *socket == "" {
+		if err := bugsnack.Pump(ctx, os.Stdin, er); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			if err := bugsnack.Pump(ctx, conn, er); err != nil {
+				backup.Report(ctx, err)
+			}
+		}(conn)
+	}
+}
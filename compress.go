@@ -0,0 +1,46 @@
+package bugsnack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+)
+
+// compressedEncoding marks values compressed by compressMetadata, in
+// a sibling key named after the value's with an "_encoding" suffix
+const compressedEncoding = "gzip+base64"
+
+// compressMetadata returns a copy of tab in which strings and byte
+// slices longer than threshold, at any depth of nested maps, are
+// gzipped and base64 encoded. Each gets a sibling "<key>_encoding"
+// key saying so, for whoever reads it to decode.
+func compressMetadata(tab map[string]interface{}, threshold int) map[string]interface{} {
+	compressed := make(map[string]interface{}, len(tab))
+	for k, v := range tab {
+		var raw []byte
+		switch v := v.(type) {
+		case map[string]interface{}:
+			compressed[k] = compressMetadata(v, threshold)
+			continue
+		case string:
+			raw = []byte(v)
+		case []byte:
+			raw = v
+		default:
+			compressed[k] = v
+			continue
+		}
+		if len(raw) <= threshold {
+			compressed[k] = v
+			continue
+		}
+
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		zw.Write(raw)
+		zw.Close()
+		compressed[k] = base64.StdEncoding.EncodeToString(b.Bytes())
+		compressed[k+"_encoding"] = compressedEncoding
+	}
+	return compressed
+}
@@ -0,0 +1,65 @@
+package bugsnack
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompressMetadata(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, CompressMetadataOver: 100}
+	page := strings.Repeat("<p>hello</p>", 50)
+
+	er.Report(context.Background(), errors.New("render failed"), &BugsnagMetadata{
+		EventMetadata: &map[string]interface{}{
+			"response": map[string]interface{}{
+				"status": 500,
+				"short":  "ok",
+				"body":   page,
+				"nested": map[string]interface{}{"raw": []byte(page)},
+			},
+		},
+	})
+
+	response := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["response"].(map[string]interface{})
+	if response["short"] != "ok" || response["status"] != 500.0 {
+		t.Errorf("expected small values to be left alone, got %v", response)
+	}
+	if _, ok := response["short_encoding"]; ok {
+		t.Errorf("expected no marker for small values, got %v", response)
+	}
+
+	nested := response["nested"].(map[string]interface{})
+	for _, tab := range []struct {
+		values map[string]interface{}
+		key    string
+	}{{response, "body"}, {nested, "raw"}} {
+		if tab.values[tab.key+"_encoding"] != "gzip+base64" {
+			t.Errorf("expected a marker for %s, got %v", tab.key, tab.values)
+			continue
+		}
+		compressed, err := base64.StdEncoding.DecodeString(tab.values[tab.key].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(strings.NewReader(string(compressed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decoded) != page {
+			t.Errorf("expected %s to decode to the original value, got %q", tab.key, decoded)
+		}
+		if len(compressed) >= len(page) {
+			t.Errorf("expected %s to shrink, got %d bytes", tab.key, len(compressed))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package bugsnack
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Config holds the commonly used settings of a BugsnagReporter,
+// for New to check and fill in
+type Config struct {
+	// APIKey is required
+	APIKey string
+
+	// ReleaseStage defaults to $BUGSNAG_RELEASE_STAGE, or
+	// "production" if that isn't set either
+	ReleaseStage        string
+	NotifyReleaseStages []string
+	AppVersion          string
+
+	// Endpoint defaults to the notify endpoint of the Region
+	Endpoint string
+	Region   Region
+
+	// Doer defaults to http.DefaultClient
+	Doer Doer
+
+	// Backup defaults to writing to os.Stderr
+	Backup ErrorReporter
+
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// ReportCanceled reports errors caused by context.Canceled or
+	// context.DeadlineExceeded, which are skipped by default
+	ReportCanceled bool
+}
+
+// New returns a BugsnagReporter configured by cfg, or an error if cfg
+// is invalid. Setting up a BugsnagReporter literal still works, but
+// gets neither the checks nor the defaults.
+func New(cfg Config) (*BugsnagReporter, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("bugsnack: APIKey is required")
+	}
+	if !cfg.Region.valid() {
+		return nil, fmt.Errorf("bugsnack: unknown Region %q", cfg.Region)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = cfg.Region.NotifyEndpoint()
+	}
+	if u, err := url.Parse(cfg.Endpoint); err != nil || !u.IsAbs() || u.Host == "" {
+		return nil, fmt.Errorf("bugsnack: Endpoint %q is not an absolute URL", cfg.Endpoint)
+	}
+	if cfg.MaxRetries < 0 || cfg.RetryBackoff < 0 {
+		return nil, errors.New("bugsnack: MaxRetries and RetryBackoff must not be negative")
+	}
+
+	if cfg.ReleaseStage == "" {
+		cfg.ReleaseStage = os.Getenv("BUGSNAG_RELEASE_STAGE")
+	}
+	if cfg.ReleaseStage == "" {
+		cfg.ReleaseStage = "production"
+	}
+	if cfg.Doer == nil {
+		cfg.Doer = http.DefaultClient
+	}
+	if cfg.Backup == nil {
+		cfg.Backup = &WriterReporter{Writer: os.Stderr}
+	}
+
+	return &BugsnagReporter{
+		APIKey:              cfg.APIKey,
+		ReleaseStage:        cfg.ReleaseStage,
+		NotifyReleaseStages: cfg.NotifyReleaseStages,
+		AppVersion:          cfg.AppVersion,
+		Endpoint:            cfg.Endpoint,
+		Region:              cfg.Region,
+		Doer:                cfg.Doer,
+		Backup:              cfg.Backup,
+		MaxRetries:          cfg.MaxRetries,
+		RetryBackoff:        cfg.RetryBackoff,
+		SkipCanceled:        !cfg.ReportCanceled,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package bugsnack
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// A ConfigDriftReporter attaches the configuration values which have
+// changed since startup under a "config_drift" tab, for services whose
+// configuration can change at runtime. Config returns the current
+// configuration; the first call (or Snapshot) records the baseline.
+type ConfigDriftReporter struct {
+	Reporter ErrorReporter
+	Config   func() map[string]interface{}
+
+	once     sync.Once
+	baseline map[string]interface{}
+}
+
+// Snapshot records the current configuration as the baseline. Call it
+// at startup; otherwise the baseline is taken at the first report.
+func (cr *ConfigDriftReporter) Snapshot() {
+	cr.once.Do(func() {
+		cr.baseline = copyConfig(cr.Config())
+	})
+}
+
+// Report sends the error on, with the drift tab added if anything
+// has changed
+func (cr *ConfigDriftReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	cr.Snapshot()
+
+	drift := map[string]interface{}{}
+	current := cr.Config()
+	for key, value := range current {
+		if old, ok := cr.baseline[key]; !ok || !reflect.DeepEqual(old, value) {
+			drift[key] = map[string]interface{}{"from": old, "to": value}
+		}
+	}
+	for key, old := range cr.baseline {
+		if _, ok := current[key]; !ok {
+			drift[key] = map[string]interface{}{"from": old, "to": nil}
+		}
+	}
+
+	if len(drift) == 0 {
+		cr.Reporter.Report(ctx, err, metadata...)
+		return
+	}
+	tab := &BugsnagMetadata{EventMetadata: &map[string]interface{}{"config_drift": drift}}
+	cr.Reporter.Report(ctx, err, withMetadata(metadata, mergeMetadata(tab, metadataFrom(metadata)))...)
+}
+
+// Flush flushes the underlying Reporter
+func (cr *ConfigDriftReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, cr.Reporter)
+}
+
+// copyConfig copies config deeply enough that later changes to
+// nested maps and slices do not change the copy
+func copyConfig(config map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if value == nil {
+			copied[key] = nil
+			continue
+		}
+		copied[key] = copyValue(reflect.ValueOf(value)).Interface()
+	}
+	return copied
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			copied.SetMapIndex(k, copyValue(v.MapIndex(k)))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(copyValue(v.Elem()))
+		return copied
+	}
+	return v
+}
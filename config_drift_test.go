@@ -0,0 +1,46 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConfigDriftReporter(t *testing.T) {
+	config := map[string]interface{}{
+		"pool_size": 10,
+		"timeout":   "5s",
+		"hosts":     []interface{}{"a", "b"},
+		"legacy":    true,
+	}
+	rr := &recordingReporter{}
+	cr := &ConfigDriftReporter{Reporter: rr, Config: func() map[string]interface{} { return config }}
+	cr.Snapshot()
+	ctx := context.Background()
+
+	cr.Report(ctx, errors.New("before"))
+	if md := rr.metadata[0]; md != nil && md.EventMetadata != nil {
+		t.Errorf("expected no drift tab without changes, got %v", *md.EventMetadata)
+	}
+
+	config["pool_size"] = 2
+	config["hosts"].([]interface{})[1] = "c"
+	config["feature"] = "on"
+	delete(config, "legacy")
+	cr.Report(ctx, errors.New("after"), &BugsnagMetadata{Severity: "warning"})
+
+	md := rr.metadata[1]
+	if md.Severity != "warning" {
+		t.Errorf("expected the per-call metadata to be kept, got %+v", md)
+	}
+	want := map[string]interface{}{
+		"pool_size": map[string]interface{}{"from": 10, "to": 2},
+		"hosts":     map[string]interface{}{"from": []interface{}{"a", "b"}, "to": []interface{}{"a", "c"}},
+		"feature":   map[string]interface{}{"from": nil, "to": "on"},
+		"legacy":    map[string]interface{}{"from": true, "to": nil},
+	}
+	if got := (*md.EventMetadata)["config_drift"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected only the changes\n%v\ngot\n%v", want, got)
+	}
+}
@@ -0,0 +1,54 @@
+package bugsnack
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewValidation(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{APIKey: testAPIKey, Endpoint: "notify.bugsnag.internal"},
+		{APIKey: testAPIKey, Endpoint: "://"},
+		{APIKey: testAPIKey, MaxRetries: -1},
+	} {
+		if er, err := New(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected, got %+v", cfg, er)
+		}
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	t.Setenv("BUGSNAG_RELEASE_STAGE", "")
+	er, err := New(Config{APIKey: testAPIKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if er.ReleaseStage != "production" || er.Endpoint != defaultEndpoint || er.Doer != http.DefaultClient || er.Backup == nil || !er.SkipCanceled {
+		t.Errorf("expected defaults to be applied, got %+v", er)
+	}
+
+	t.Setenv("BUGSNAG_RELEASE_STAGE", "staging")
+	if er, err = New(Config{APIKey: testAPIKey}); err != nil || er.ReleaseStage != "staging" {
+		t.Errorf("expected the release stage from the environment, got %+v (%v)", er, err)
+	}
+
+	doer := &MockDoer{}
+	backup := &recordingReporter{}
+	er, err = New(Config{
+		APIKey:       testAPIKey,
+		ReleaseStage: "development",
+		Endpoint:     "https://bugsnag.internal",
+		Doer:         doer,
+		Backup:       backup,
+		MaxRetries:   2,
+
+		ReportCanceled: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if er.ReleaseStage != "development" || er.Endpoint != "https://bugsnag.internal" || er.Doer != doer || er.Backup != backup || er.MaxRetries != 2 || er.SkipCanceled {
+		t.Errorf("expected the given settings to be kept, got %+v", er)
+	}
+}
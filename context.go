@@ -0,0 +1,73 @@
+package bugsnack
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the given
+// request/correlation ID
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID stored in ctx by
+// WithCorrelationID, or "" if there is none
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+type tagsKey struct{}
+
+// WithTags returns a copy of ctx carrying tags, merged with any tags
+// already stored in ctx (tags given here win). They are added to every
+// event reported with the context.
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string)
+	for k, v := range mergeTags(ContextTags(ctx), tags) {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// ContextTags returns the tags stored in ctx by WithTags
+func ContextTags(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
+
+type releaseStageKey struct{}
+
+// WithReleaseStage returns a copy of ctx carrying a release stage,
+// which reporters use instead of their own
+func WithReleaseStage(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, releaseStageKey{}, stage)
+}
+
+// ContextReleaseStage returns the release stage stored in ctx by
+// WithReleaseStage, or "" if there is none
+func ContextReleaseStage(ctx context.Context) string {
+	stage, _ := ctx.Value(releaseStageKey{}).(string)
+	return stage
+}
+
+// isCanceled reports whether err was caused by a context being
+// canceled or running out of time
+func isCanceled(err error) bool {
+	return MatchIs(context.Canceled).Matches(err) || MatchIs(context.DeadlineExceeded).Matches(err)
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
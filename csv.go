@@ -0,0 +1,69 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+)
+
+// A CSVReporter writes a row per error to Writer, for triaging errors
+// in a spreadsheet. The columns are time, class, message, severity,
+// the top stack frame, then one per tag listed in Tags. The header is
+// written before the first row.
+type CSVReporter struct {
+	Writer io.Writer
+	Tags   []string
+
+	mu            sync.Mutex
+	headerWritten bool
+}
+
+// Report writes the error as a CSV row
+func (cr *CSVReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = cr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE behaves like Report, but returns any error encountered
+// while writing
+func (cr *CSVReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	if cr.Writer == nil || err == nil {
+		return nil
+	}
+
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+	topFrame := ""
+	if stack := errorStack(err, callers(1)); len(stack) > 0 {
+		topFrame = fmt.Sprintf("%s %s:%d", funcName(stack[0].Function), path.Base(stack[0].File), stack[0].Line)
+	}
+	row := []string{
+		now().UTC().Format(eventTimeFormat),
+		errorClass(err, md),
+		err.Error(),
+		severity(md),
+		topFrame,
+	}
+	tags := mergeTags(ContextTags(ctx), md.Tags)
+	for _, name := range cr.Tags {
+		row = append(row, tags[name])
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	w := csv.NewWriter(cr.Writer)
+	if !cr.headerWritten {
+		header := append([]string{"time", "class", "message", "severity", "frame"}, cr.Tags...)
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		cr.headerWritten = true
+	}
+	if err := w.Write(row); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
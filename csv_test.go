@@ -0,0 +1,80 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCSVReporter(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC) }
+
+	var buf bytes.Buffer
+	cr := &CSVReporter{Writer: &buf, Tags: []string{"team", "region"}}
+	ctx := WithTags(context.Background(), map[string]string{"region": "eu"})
+
+	cr.Report(ctx, errors.New(`parsing "a, b": unexpected`+"\nnewline"), &BugsnagMetadata{
+		Severity: "warning",
+		Tags:     map[string]string{"team": "payments"},
+	})
+	cr.Report(context.Background(), errors.New("plain"))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("expected well-formed CSV, got %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected a header and two rows, got %q", rows)
+	}
+	if want := []string{"time", "class", "message", "severity", "frame", "team", "region"}; !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("expected header %q, got %q", want, rows[0])
+	}
+
+	first := rows[1]
+	want := []string{"2017-05-05T12:00:00.000Z", "*errors.errorString", `parsing "a, b": unexpected` + "\nnewline", "warning"}
+	if !reflect.DeepEqual(first[:4], want) {
+		t.Errorf("expected %q, got %q", want, first[:4])
+	}
+	if !strings.HasPrefix(first[4], "TestCSVReporter csv_test.go:") {
+		t.Errorf("expected the top frame to be the test, got %q", first[4])
+	}
+	if first[5] != "payments" || first[6] != "eu" {
+		t.Errorf("expected the tags, got %q", first[5:])
+	}
+	if second := rows[2]; second[3] != "error" || second[5] != "" || second[6] != "" {
+		t.Errorf("expected defaults and empty tags, got %q", second)
+	}
+}
+
+func TestCSVReporterConcurrency(t *testing.T) {
+	w := &chunkedWriter{}
+	cr := &CSVReporter{Writer: w}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				cr.Report(context.Background(), fmt.Errorf("error, %d.%d", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	rows, err := csv.NewReader(&w.buf).ReadAll()
+	if err != nil {
+		t.Fatalf("expected well-formed CSV, got %v", err)
+	}
+	if len(rows) != 201 || rows[0][0] != "time" {
+		t.Errorf("expected a single header and 200 rows, got %d rows", len(rows))
+	}
+}
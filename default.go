@@ -0,0 +1,39 @@
+package bugsnack
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	defaultMu       sync.RWMutex
+	defaultReporter ErrorReporter
+)
+
+// SetDefault sets the reporter used by the package-level Report, and
+// by ReportPanic when given a nil reporter, e.g. from main.main. Passing
+// nil goes back to writing errors to stderr. It is safe to call at any
+// time.
+func SetDefault(er ErrorReporter) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultReporter = er
+}
+
+// Default returns the reporter set with SetDefault, or one writing to
+// stderr if there is none
+func Default() ErrorReporter {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultReporter == nil {
+		return &WriterReporter{Writer: stderr}
+	}
+	return defaultReporter
+}
+
+// Report reports err to the default reporter (see SetDefault), for
+// code which has no ErrorReporter passed to it. Prefer passing one
+// where you can, as that is easier to test.
+func Report(ctx context.Context, err error, metadata ...interface{}) {
+	Default().Report(ctx, err, metadata...)
+}
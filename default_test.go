@@ -0,0 +1,58 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestDefaultReporter(t *testing.T) {
+	var out bytes.Buffer
+	defer func(w io.Writer) { stderr = w }(stderr)
+	stderr = &out
+	defer SetDefault(nil)
+
+	Report(context.Background(), errors.New("unset"))
+	if out.String() != "unset\n" {
+		t.Errorf("expected errors on stderr without a default, got %q", out.String())
+	}
+
+	rr := &recordingReporter{}
+	SetDefault(rr)
+	Report(context.Background(), errors.New("set"), &BugsnagMetadata{Severity: "warning"})
+	panicWith(nil, "boom")
+	if len(rr.errs) != 2 || rr.errs[0].Error() != "set" || rr.metadata[0].Severity != "warning" {
+		t.Fatalf("expected reports to go to the default, got %v", rr.errs)
+	}
+	if rr.errs[1].Error() != "panic: boom" || !rr.metadata[1].Unhandled {
+		t.Errorf("expected ReportPanic with a nil reporter to use the default, got %v", rr.errs[1])
+	}
+
+	SetDefault(nil)
+	Report(context.Background(), errors.New("reset"))
+	if out.String() != "unset\nreset\n" {
+		t.Errorf("expected stderr again after resetting, got %q", out.String())
+	}
+}
+
+func TestDefaultReporterConcurrency(t *testing.T) {
+	defer SetDefault(nil)
+	SetDefault(&stubReporter{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetDefault(&stubReporter{})
+		}()
+		go func() {
+			defer wg.Done()
+			Report(context.Background(), errors.New("concurrent"))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,33 @@
+package bugsnack
+
+import "context"
+
+// A DefaultsReporter merges Defaults into the metadata of every error
+// before passing it on, with the values given to Report, and tags
+// stored in the context by WithTags, winning
+type DefaultsReporter struct {
+	Reporter ErrorReporter
+	Defaults *BugsnagMetadata
+}
+
+// WithDefaults wraps inner so that defaults (e.g. service name, version
+// or region) are attached to every error without repeating them at
+// each call site
+func WithDefaults(inner ErrorReporter, defaults *BugsnagMetadata) ErrorReporter {
+	return &DefaultsReporter{Reporter: inner, Defaults: defaults}
+}
+
+// Report sends the error on with the merged metadata
+func (dr *DefaultsReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	defaults := dr.Defaults
+	if tags := ContextTags(ctx); len(tags) > 0 && defaults != nil && len(defaults.Tags) > 0 {
+		defaults = mergeMetadata(defaults, &BugsnagMetadata{Tags: tags})
+	}
+	merged := mergeMetadata(defaults, metadataFrom(metadata))
+	dr.Reporter.Report(ctx, err, withMetadata(metadata, merged)...)
+}
+
+// Flush flushes the underlying Reporter
+func (dr *DefaultsReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, dr.Reporter)
+}
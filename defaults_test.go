@@ -0,0 +1,52 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithDefaults(t *testing.T) {
+	rr := &recordingReporter{}
+	er := WithDefaults(rr, &BugsnagMetadata{
+		Context:  "billing",
+		Severity: "warning",
+		EventMetadata: &map[string]interface{}{
+			"service": map[string]interface{}{
+				"name":   "billing-api",
+				"region": "eu-west-1",
+			},
+		},
+	})
+
+	er.Report(context.Background(), errors.New("no metadata"))
+	er.Report(context.Background(), errors.New("with metadata"), &BugsnagMetadata{
+		Severity: "error",
+		EventMetadata: &map[string]interface{}{
+			"service": map[string]interface{}{
+				"region": "us-east-1",
+			},
+			"request": map[string]interface{}{
+				"path": "/invoices",
+			},
+		},
+	})
+
+	md := rr.metadata[0]
+	service := (*md.EventMetadata)["service"].(map[string]interface{})
+	if md.Context != "billing" || md.Severity != "warning" || service["name"] != "billing-api" {
+		t.Errorf("expected defaults without per-call metadata, got %+v", md)
+	}
+
+	md = rr.metadata[1]
+	service = (*md.EventMetadata)["service"].(map[string]interface{})
+	if md.Context != "billing" || md.Severity != "error" {
+		t.Errorf("expected per-call severity over the default context, got %+v", md)
+	}
+	if service["name"] != "billing-api" || service["region"] != "us-east-1" {
+		t.Errorf("expected tabs to be merged with per-call values winning, got %v", service)
+	}
+	if (*md.EventMetadata)["request"] == nil {
+		t.Errorf("expected per-call tabs to be kept")
+	}
+}
@@ -0,0 +1,12 @@
+package desktop
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// notify is replaced in tests
+var notify = func(title, message string) error {
+	script := fmt.Sprintf("display notification %q with title %q", message, title)
+	return exec.Command("osascript", "-e", script).Run()
+}
@@ -0,0 +1,8 @@
+package desktop
+
+import "os/exec"
+
+// notify is replaced in tests
+var notify = func(title, message string) error {
+	return exec.Command("notify-send", title, message).Run()
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package desktop
+
+// notify is replaced in tests
+var notify = func(title, message string) error {
+	return nil
+}
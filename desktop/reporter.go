@@ -0,0 +1,59 @@
+// Package desktop provides a bugsnack.ErrorReporter which shows errors
+// as native desktop notifications, which is handy during local
+// development. On platforms without support it only delegates.
+package desktop
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fromatob/bugsnack"
+)
+
+// maxMessageLength bounds the notification text, in runes
+const maxMessageLength = 200
+
+// A NotifyReporter shows a desktop notification for each error,
+// then passes it on to Reporter (if set)
+type NotifyReporter struct {
+	Reporter bugsnack.ErrorReporter
+
+	// Title of the notifications, defaulting to "bugsnack"
+	Title string
+}
+
+// Report notifies, then sends the error on to the underlying Reporter
+func (nr *NotifyReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	title, message := nr.format(err)
+	_ = notify(title, message)
+
+	if nr.Reporter != nil {
+		nr.Reporter.Report(ctx, err, metadata...)
+	}
+}
+
+// Flush flushes the underlying Reporter
+func (nr *NotifyReporter) Flush(ctx context.Context) error {
+	if nr.Reporter == nil {
+		return nil
+	}
+	return bugsnack.Flush(ctx, nr.Reporter)
+}
+
+// format returns the title and the first line of the error message,
+// shortened to fit a notification
+func (nr *NotifyReporter) format(err error) (title, message string) {
+	title = nr.Title
+	if title == "" {
+		title = "bugsnack"
+	}
+
+	message = err.Error()
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = message[:i]
+	}
+	if runes := []rune(message); len(runes) > maxMessageLength {
+		message = string(runes[:maxMessageLength-1]) + "…"
+	}
+	return title, message
+}
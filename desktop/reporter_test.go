@@ -0,0 +1,47 @@
+package desktop
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/fromatob/bugsnack"
+)
+
+func TestNotifyReporter(t *testing.T) {
+	type notification struct{ title, message string }
+	var shown []notification
+	defer func(f func(string, string) error) { notify = f }(notify)
+	notify = func(title, message string) error {
+		shown = append(shown, notification{title, message})
+		return nil
+	}
+
+	var out strings.Builder
+	nr := &NotifyReporter{
+		Reporter: &bugsnack.WriterReporter{Writer: &out},
+		Title:    "billing-api",
+	}
+
+	nr.Report(context.Background(), errors.New("db timeout\nat query.go:42"))
+	nr.Report(context.Background(), errors.New(strings.Repeat("é", 500)))
+
+	if len(shown) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(shown))
+	}
+	if shown[0].title != "billing-api" || shown[0].message != "db timeout" {
+		t.Errorf("expected the first line of the error, got %+v", shown[0])
+	}
+	if n := utf8.RuneCountInString(shown[1].message); n != maxMessageLength || !strings.HasSuffix(shown[1].message, "…") {
+		t.Errorf("expected a shortened message, got %d runes", n)
+	}
+	if !strings.HasPrefix(out.String(), "db timeout\n") {
+		t.Errorf("expected errors to be passed on, got %q", out.String())
+	}
+
+	if title, _ := (&NotifyReporter{}).format(errors.New("x")); title != "bugsnack" {
+		t.Errorf("expected the default title, got %q", title)
+	}
+}
@@ -0,0 +1,160 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultDigestSamples = 3
+
+// A DigestReporter collects errors and sends a single report
+// summarizing them every Interval, with the number of errors in each
+// group (keyed by GroupingHash, or the error message) and a few sample
+// messages under a "digest" tab. It suits noisy periodic jobs, where a
+// digest is more useful than every single failure.
+//
+// A digest is sent once its Interval is over, even if nothing else is
+// reported, and by Flush, which should be called before the program
+// exits.
+type DigestReporter struct {
+	Reporter ErrorReporter
+	Interval time.Duration
+
+	// Samples is how many messages are kept per group,
+	// defaulting to 3
+	Samples int
+
+	mu     sync.Mutex
+	start  time.Time
+	total  int
+	groups map[string]*digestGroup
+	timer  interface{ Stop() bool }
+}
+
+type digestGroup struct {
+	count   int
+	samples []string
+}
+
+// Report adds the error to the current digest, first sending the
+// previous one if its Interval is over
+func (dr *DigestReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	key := groupingKey(err, metadataFrom(metadata))
+	t := now()
+
+	dr.mu.Lock()
+	var digest *digest
+	if !dr.start.IsZero() && t.Sub(dr.start) >= dr.Interval {
+		digest = dr.take()
+	}
+	if dr.start.IsZero() {
+		dr.start = t
+		dr.groups = map[string]*digestGroup{}
+		if dr.Interval > 0 {
+			dr.timer = afterFunc(dr.Interval, func() { dr.expire(t) })
+		}
+	}
+	group, ok := dr.groups[key]
+	if !ok {
+		group = &digestGroup{}
+		dr.groups[key] = group
+	}
+	group.count++
+	dr.total++
+	samples := dr.Samples
+	if samples <= 0 {
+		samples = defaultDigestSamples
+	}
+	if len(group.samples) < samples {
+		group.samples = append(group.samples, err.Error())
+	}
+	dr.mu.Unlock()
+
+	if digest != nil {
+		digest.report(ctx, dr.Reporter)
+	}
+}
+
+// Flush sends the current digest, if there is one, then flushes the
+// underlying Reporter
+func (dr *DigestReporter) Flush(ctx context.Context) error {
+	dr.mu.Lock()
+	var digest *digest
+	if !dr.start.IsZero() {
+		digest = dr.take()
+	}
+	dr.mu.Unlock()
+
+	if digest != nil {
+		digest.report(ctx, dr.Reporter)
+	}
+	return Flush(ctx, dr.Reporter)
+}
+
+// expire sends the digest started at start, unless it was already
+// sent
+func (dr *DigestReporter) expire(start time.Time) {
+	dr.mu.Lock()
+	var digest *digest
+	if dr.start.Equal(start) {
+		digest = dr.take()
+	}
+	dr.mu.Unlock()
+
+	if digest != nil {
+		digest.report(context.Background(), dr.Reporter)
+	}
+}
+
+type digest struct {
+	start  time.Time
+	total  int
+	groups map[string]*digestGroup
+}
+
+// take returns the current digest and starts afresh
+func (dr *DigestReporter) take() *digest {
+	d := &digest{start: dr.start, total: dr.total, groups: dr.groups}
+	if dr.timer != nil {
+		dr.timer.Stop()
+	}
+	dr.start, dr.total, dr.groups, dr.timer = time.Time{}, 0, nil, nil
+	return d
+}
+
+func (d *digest) report(ctx context.Context, er ErrorReporter) {
+	keys := make([]string, 0, len(d.groups))
+	for key := range d.groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if ci, cj := d.groups[keys[i]].count, d.groups[keys[j]].count; ci != cj {
+			return ci > cj
+		}
+		return keys[i] < keys[j]
+	})
+
+	groups := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		groups = append(groups, map[string]interface{}{
+			"key":     key,
+			"count":   d.groups[key].count,
+			"samples": d.groups[key].samples,
+		})
+	}
+
+	err := fmt.Errorf("digest: %d errors in %d groups since %s", d.total, len(keys), d.start.Format(time.RFC3339))
+	er.Report(ctx, err, &BugsnagMetadata{
+		GroupingHash: "bugsnack.digest",
+		EventMetadata: &map[string]interface{}{
+			"digest": map[string]interface{}{
+				"since":  d.start.Format(time.RFC3339),
+				"total":  d.total,
+				"groups": groups,
+			},
+		},
+	})
+}
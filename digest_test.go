@@ -0,0 +1,98 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDigestReporter(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	inner := &recordingReporter{}
+	dr := &DigestReporter{Reporter: inner, Interval: time.Hour, Samples: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		dr.Report(ctx, fmt.Errorf("timeout fetching feed %d", i), &BugsnagMetadata{GroupingHash: "feed.timeout"})
+		clock = clock.Add(time.Minute)
+	}
+	dr.Report(ctx, errors.New("bad row"))
+	if len(inner.errs) != 0 {
+		t.Fatalf("expected nothing before the interval is over, got %v", inner.errs)
+	}
+
+	clock = clock.Add(time.Hour)
+	dr.Report(ctx, errors.New("bad row"))
+	if len(inner.errs) != 1 {
+		t.Fatalf("expected a digest once the interval is over, got %d reports", len(inner.errs))
+	}
+	if want := "digest: 6 errors in 2 groups since 2017-05-05T12:00:00Z"; inner.errs[0].Error() != want {
+		t.Errorf("expected %q, got %q", want, inner.errs[0])
+	}
+	tab := (*inner.metadata[0].EventMetadata)["digest"].(map[string]interface{})
+	groups := tab["groups"].([]interface{})
+	top := groups[0].(map[string]interface{})
+	if top["key"] != "feed.timeout" || top["count"] != 5 {
+		t.Errorf("expected the biggest group first, got %v", top)
+	}
+	if samples := top["samples"].([]string); len(samples) != 2 || samples[0] != "timeout fetching feed 0" {
+		t.Errorf("expected 2 samples, got %v", samples)
+	}
+	if next := groups[1].(map[string]interface{}); next["key"] != "bad row" || next["count"] != 1 {
+		t.Errorf("unexpected group %v", next)
+	}
+
+	// the report which sent the digest starts the next one
+	if err := dr.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.errs) != 2 || inner.errs[1].Error() != "digest: 1 errors in 1 groups since 2017-05-05T13:05:00Z" {
+		t.Errorf("expected the pending digest to be flushed, got %v", inner.errs)
+	}
+	if err := dr.Flush(ctx); err != nil || len(inner.errs) != 2 {
+		t.Errorf("expected nothing more to flush, got %v", inner.errs)
+	}
+}
+
+func TestDigestReporterTimer(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+	timers := stubAfterFunc(t)
+
+	inner := &recordingReporter{}
+	dr := &DigestReporter{Reporter: inner, Interval: time.Hour}
+	ctx := context.Background()
+
+	dr.Report(ctx, errors.New("bad row"))
+	clock = clock.Add(time.Minute)
+	dr.Report(ctx, errors.New("bad row"))
+	if len(*timers) != 1 || (*timers)[0].d != time.Hour {
+		t.Fatalf("expected a single timer for the interval, got %v", *timers)
+	}
+
+	// the process goes idle, the timer sends the digest anyway
+	clock = clock.Add(time.Hour)
+	(*timers)[0].f()
+	if len(inner.errs) != 1 || inner.errs[0].Error() != "digest: 2 errors in 1 groups since 2017-05-05T12:00:00Z" {
+		t.Fatalf("expected the digest on time, got %v", inner.errs)
+	}
+
+	dr.Report(ctx, errors.New("bad row"))
+	if err := dr.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(*timers) != 2 || !(*timers)[1].stopped {
+		t.Fatalf("expected flushing to stop the next timer, got %v", *timers)
+	}
+	// a timer firing as the digest is flushed must not send it twice
+	(*timers)[1].f()
+	if len(inner.errs) != 2 {
+		t.Errorf("expected 2 digests, got %v", inner.errs)
+	}
+}
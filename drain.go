@@ -0,0 +1,68 @@
+package bugsnack
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// A DrainReporter quiets the errors expected while a service shuts
+// down (e.g. canceled contexts and closed connections) once
+// SetDraining(true) has been called, while still passing on anything
+// unexpected. Noise errors are dropped, or with Downgrade, reported
+// with severity "info".
+type DrainReporter struct {
+	Reporter  ErrorReporter
+	Downgrade bool
+
+	// Noise reports whether an error is expected during shutdown,
+	// defaulting to IsShutdownNoise
+	Noise func(err error) bool
+
+	draining int32
+}
+
+// IsShutdownNoise reports whether err is caused by a context being
+// canceled or timing out, a closed network connection or a closed
+// http.Server
+func IsShutdownNoise(err error) bool {
+	return isCanceled(err) || MatchIs(net.ErrClosed).Matches(err) || MatchIs(http.ErrServerClosed).Matches(err)
+}
+
+// SetDraining switches suppression on or off
+func (dr *DrainReporter) SetDraining(draining bool) {
+	var v int32
+	if draining {
+		v = 1
+	}
+	atomic.StoreInt32(&dr.draining, v)
+}
+
+// Draining reports whether the service is shutting down
+func (dr *DrainReporter) Draining() bool {
+	return atomic.LoadInt32(&dr.draining) == 1
+}
+
+// Report sends the error on to the underlying Reporter, unless it is
+// noise during shutdown
+func (dr *DrainReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	noise := dr.Noise
+	if noise == nil {
+		noise = IsShutdownNoise
+	}
+	if !dr.Draining() || !noise(err) {
+		dr.Reporter.Report(ctx, err, metadata...)
+		return
+	}
+	if !dr.Downgrade {
+		return
+	}
+	info := mergeMetadata(metadataFrom(metadata), &BugsnagMetadata{Severity: "info"})
+	dr.Reporter.Report(ctx, err, withMetadata(metadata, info)...)
+}
+
+// Flush flushes the underlying Reporter
+func (dr *DrainReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, dr.Reporter)
+}
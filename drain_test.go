@@ -0,0 +1,44 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestDrainReporter(t *testing.T) {
+	rr := &recordingReporter{}
+	dr := &DrainReporter{Reporter: rr}
+	ctx := context.Background()
+
+	canceled := fmt.Errorf("handling request: %w", context.Canceled)
+	closed := &net.OpError{Op: "read", Err: net.ErrClosed}
+	unexpected := errors.New("failed to flush cache")
+
+	dr.Report(ctx, canceled)
+	if len(rr.errs) != 1 {
+		t.Fatalf("expected noise to be reported before draining, got %v", rr.errs)
+	}
+
+	dr.SetDraining(true)
+	dr.Report(ctx, canceled)
+	dr.Report(ctx, closed)
+	dr.Report(ctx, unexpected)
+	if len(rr.errs) != 2 || rr.errs[1] != unexpected {
+		t.Fatalf("expected only the unexpected error while draining, got %v", rr.errs)
+	}
+
+	dr.Downgrade = true
+	dr.Report(ctx, closed, &BugsnagMetadata{Severity: "error", Context: "server"})
+	if md := rr.metadata[2]; len(rr.errs) != 3 || md.Severity != "info" || md.Context != "server" {
+		t.Errorf("expected noise downgraded to info, got %v %+v", rr.errs, md)
+	}
+
+	dr.SetDraining(false)
+	dr.Report(ctx, closed)
+	if md := rr.metadata[3]; len(rr.errs) != 4 || severity(md) != "error" {
+		t.Errorf("expected noise to be reported again after draining, got %v", rr.errs)
+	}
+}
@@ -0,0 +1,28 @@
+package bugsnack
+
+import (
+	"errors"
+	"os"
+)
+
+// NewFromEnv returns a BugsnagReporter configured from the
+// conventional environment variables:
+//
+//	BUGSNAG_API_KEY          (required)
+//	BUGSNAG_RELEASE_STAGE
+//	BUGSNAG_APP_VERSION
+//	BUGSNAG_NOTIFY_ENDPOINT
+//
+// Anything else gets the defaults of New.
+func NewFromEnv() (*BugsnagReporter, error) {
+	apiKey := os.Getenv("BUGSNAG_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("bugsnack: BUGSNAG_API_KEY is not set")
+	}
+
+	return New(Config{
+		APIKey:     apiKey,
+		AppVersion: os.Getenv("BUGSNAG_APP_VERSION"),
+		Endpoint:   os.Getenv("BUGSNAG_NOTIFY_ENDPOINT"),
+	})
+}
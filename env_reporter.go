@@ -0,0 +1,41 @@
+package bugsnack
+
+import (
+	"context"
+	"os"
+	"runtime"
+)
+
+// An EnvReporter attaches host facts (hostname, PID and number of CPUs)
+// and selected environment variables under an "env" tab, which helps
+// spot configuration drift between instances
+type EnvReporter struct {
+	Reporter ErrorReporter
+
+	// AllowEnv lists the environment variables to attach. Nothing
+	// else is taken from the environment, so secrets stay put.
+	AllowEnv []string
+}
+
+// Report sends the error on with the env tab added
+func (er *EnvReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	host, _ := os.Hostname()
+	tab := map[string]interface{}{
+		"hostname": host,
+		"pid":      os.Getpid(),
+		"numCPU":   runtime.NumCPU(),
+	}
+	for _, name := range er.AllowEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			tab[name] = value
+		}
+	}
+
+	env := &BugsnagMetadata{EventMetadata: &map[string]interface{}{"env": tab}}
+	er.Reporter.Report(ctx, err, withMetadata(metadata, mergeMetadata(env, metadataFrom(metadata)))...)
+}
+
+// Flush flushes the underlying Reporter
+func (er *EnvReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, er.Reporter)
+}
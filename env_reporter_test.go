@@ -0,0 +1,34 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestEnvReporter(t *testing.T) {
+	t.Setenv("REGION", "eu-west-1")
+	t.Setenv("DATABASE_PASSWORD", "hunter2")
+
+	rr := &recordingReporter{}
+	er := &EnvReporter{Reporter: rr, AllowEnv: []string{"REGION", "UNSET_VARIABLE"}}
+	er.Report(context.Background(), errors.New("env test"), &BugsnagMetadata{Severity: "warning"})
+
+	md := rr.metadata[0]
+	if md.Severity != "warning" {
+		t.Errorf("expected the per-call metadata to be kept, got %+v", md)
+	}
+	tab := (*md.EventMetadata)["env"].(map[string]interface{})
+	if tab["REGION"] != "eu-west-1" {
+		t.Errorf("expected allow-listed variables, got %v", tab)
+	}
+	for _, name := range []string{"DATABASE_PASSWORD", "UNSET_VARIABLE"} {
+		if _, ok := tab[name]; ok {
+			t.Errorf("expected %s not to be attached, got %v", name, tab)
+		}
+	}
+	if tab["pid"] != os.Getpid() || tab["numCPU"] == nil || tab["hostname"] == nil {
+		t.Errorf("expected host facts, got %v", tab)
+	}
+}
@@ -0,0 +1,38 @@
+package bugsnack
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("BUGSNAG_API_KEY", "")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("expected an error without an API key")
+	}
+
+	t.Setenv("BUGSNAG_API_KEY", "abc123")
+	t.Setenv("BUGSNAG_RELEASE_STAGE", "staging")
+	t.Setenv("BUGSNAG_APP_VERSION", "1.2.3")
+	t.Setenv("BUGSNAG_NOTIFY_ENDPOINT", "https://bugsnag.internal")
+
+	er, err := NewFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if er.APIKey != "abc123" || er.ReleaseStage != "staging" || er.AppVersion != "1.2.3" || er.Endpoint != "https://bugsnag.internal" {
+		t.Errorf("unexpected reporter %+v", er)
+	}
+	if er.Doer != http.DefaultClient || er.Backup == nil {
+		t.Errorf("expected http.DefaultClient and a Backup, got %+v", er)
+	}
+
+	t.Setenv("BUGSNAG_NOTIFY_ENDPOINT", "")
+	er, err = NewFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if er.endpoint() != defaultEndpoint {
+		t.Errorf("expected the default endpoint, got %q", er.endpoint())
+	}
+}
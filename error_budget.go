@@ -0,0 +1,84 @@
+package bugsnack
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// An ErrorBudgetReporter guards against a reporter which keeps failing.
+// Once more than MaxFailureRate of the reports in a Window fail (and
+// there were at least MinReports), Reporter is disabled for Cooldown,
+// during which errors go straight to Backup. Errors Reporter fails to
+// deliver also go to Backup.
+type ErrorBudgetReporter struct {
+	Reporter ErrorReporterE
+	Backup   ErrorReporter
+
+	Window         time.Duration
+	MaxFailureRate float64
+	MinReports     int
+	Cooldown       time.Duration
+
+	mu            sync.Mutex
+	windowStart   time.Time
+	reports       int
+	failures      int
+	disabledUntil time.Time
+}
+
+// Report sends the error to Reporter, or to Backup if Reporter is
+// disabled or fails
+func (er *ErrorBudgetReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	if !er.enabled(now()) {
+		er.backup(ctx, err, metadata...)
+		return
+	}
+
+	reportErr := er.Reporter.ReportE(ctx, err, metadata...)
+	er.record(now(), reportErr != nil)
+	if reportErr != nil {
+		er.backup(ctx, err, metadata...)
+	}
+}
+
+// enabled reports whether Reporter is to be used at t, starting a new
+// window once the cooldown or the current window is over
+func (er *ErrorBudgetReporter) enabled(t time.Time) bool {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if t.Before(er.disabledUntil) {
+		return false
+	}
+	if !er.disabledUntil.IsZero() || t.Sub(er.windowStart) >= er.Window {
+		er.disabledUntil = time.Time{}
+		er.windowStart, er.reports, er.failures = t, 0, 0
+	}
+	return true
+}
+
+// record counts a report, disabling Reporter if it used up its budget
+func (er *ErrorBudgetReporter) record(t time.Time, failed bool) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	er.reports++
+	if failed {
+		er.failures++
+	}
+	if er.reports >= er.MinReports && float64(er.failures) > er.MaxFailureRate*float64(er.reports) {
+		er.disabledUntil = t.Add(er.Cooldown)
+	}
+}
+
+func (er *ErrorBudgetReporter) backup(ctx context.Context, err error, metadata ...interface{}) {
+	if er.Backup != nil {
+		er.Backup.Report(ctx, err, metadata...)
+	}
+}
+
+// Flush flushes Reporter and Backup
+func (er *ErrorBudgetReporter) Flush(ctx context.Context) error {
+	return (&MultiReporter{Reporters: []ErrorReporter{er.Reporter, er.Backup}}).Flush(ctx)
+}
@@ -0,0 +1,75 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorBudgetReporter(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	inner := &stubReporter{err: errors.New("integration down")}
+	backup := &recordingReporter{}
+	er := &ErrorBudgetReporter{
+		Reporter:       inner,
+		Backup:         backup,
+		Window:         time.Minute,
+		MaxFailureRate: 0.5,
+		MinReports:     4,
+		Cooldown:       5 * time.Minute,
+	}
+	ctx := context.Background()
+
+	// failures below MinReports don't disable the reporter
+	for i := 0; i < 3; i++ {
+		er.Report(ctx, errors.New("failing"))
+	}
+	if inner.calls != 3 || len(backup.errs) != 3 {
+		t.Fatalf("expected 3 attempts, all backed up, got %d and %d", inner.calls, len(backup.errs))
+	}
+
+	er.Report(ctx, errors.New("over budget"))
+	er.Report(ctx, errors.New("disabled"))
+	if inner.calls != 4 {
+		t.Errorf("expected the reporter to be disabled after using its budget, got %d calls", inner.calls)
+	}
+	if len(backup.errs) != 5 || backup.errs[4].Error() != "disabled" {
+		t.Errorf("expected errors to go to the backup while disabled, got %v", backup.errs)
+	}
+
+	clock = clock.Add(4 * time.Minute)
+	er.Report(ctx, errors.New("still disabled"))
+	if inner.calls != 4 {
+		t.Errorf("expected the reporter to stay disabled during the cooldown, got %d calls", inner.calls)
+	}
+
+	// healed in the meantime
+	inner.err = nil
+	clock = clock.Add(time.Minute)
+	er.Report(ctx, errors.New("re-enabled"))
+	if inner.calls != 5 || len(backup.errs) != 6 {
+		t.Errorf("expected the reporter to be re-enabled after the cooldown, got %d calls and %d backups", inner.calls, len(backup.errs))
+	}
+}
+
+func TestErrorBudgetReporterWindow(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	inner := &stubReporter{err: errors.New("flaky")}
+	er := &ErrorBudgetReporter{Reporter: inner, Window: time.Minute, MaxFailureRate: 0.5, MinReports: 2, Cooldown: time.Minute}
+
+	// one failure per window never adds up to the minimum
+	for i := 0; i < 5; i++ {
+		er.Report(context.Background(), errors.New("occasional"))
+		clock = clock.Add(time.Minute)
+	}
+	if inner.calls != 5 {
+		t.Errorf("expected failures in separate windows not to disable the reporter, got %d calls", inner.calls)
+	}
+}
@@ -2,6 +2,7 @@ package bugsnack
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -12,6 +13,29 @@ type ErrorReporter interface {
 	Report(ctx context.Context, err error, metadata ...interface{})
 }
 
+// An ErrorReporterE is an ErrorReporter that can also tell
+// the caller whether the error was delivered
+type ErrorReporterE interface {
+	ErrorReporter
+	ReportE(ctx context.Context, err error, metadata ...interface{}) error
+}
+
+// A Flusher is a reporter which holds on to state, such as pending or
+// batched errors, that should be flushed before the program exits.
+// Reporters wrapping other reporters pass Flush on to them, so a whole
+// tree of reporters can be flushed from its root.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Flush flushes er if it is a Flusher, and does nothing otherwise
+func Flush(ctx context.Context, er ErrorReporter) error {
+	if f, ok := er.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
 // A MultiReporter is capable of sending a single error
 // to multiple ErrorReporters
 type MultiReporter struct {
@@ -21,15 +45,37 @@ type MultiReporter struct {
 // Report sends the same error to all underlying Reporters
 // concurrently.
 func (mr *MultiReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = mr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE sends the same error to all underlying Reporters
+// concurrently, returning the joined errors of those which failed.
+// Reporters which are not ErrorReporterEs are assumed to succeed.
+func (mr *MultiReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	errs := make([]error, len(mr.Reporters))
 	var wg sync.WaitGroup
-	for _, er := range mr.Reporters {
+	for i, er := range mr.Reporters {
 		wg.Add(1)
-		go func(wg *sync.WaitGroup, er ErrorReporter) {
+		go func(wg *sync.WaitGroup, i int, er ErrorReporter) {
 			defer wg.Done()
-			er.Report(ctx, err)
-		}(&wg, er)
+			if ere, ok := er.(ErrorReporterE); ok {
+				errs[i] = ere.ReportE(ctx, err, metadata...)
+				return
+			}
+			er.Report(ctx, err, metadata...)
+		}(&wg, i, er)
 	}
 	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Flush flushes all underlying Reporters
+func (mr *MultiReporter) Flush(ctx context.Context) error {
+	var errs []error
+	for _, er := range mr.Reporters {
+		errs = append(errs, Flush(ctx, er))
+	}
+	return errors.Join(errs...)
 }
 
 // A WriterReporter writes errors to an io.Writer
@@ -39,9 +85,75 @@ type WriterReporter struct {
 
 // Report printf's the error using %s, then writes it to the
 // underlying writer
-func (wr *WriterReporter) Report(_ context.Context, err error, metadata ...interface{}) {
+func (wr *WriterReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = wr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE behaves like Report, but returns any error
+// encountered while writing
+func (wr *WriterReporter) ReportE(_ context.Context, err error, metadata ...interface{}) error {
 	if wr.Writer == nil {
-		return
+		return nil
+	}
+
+	// errors with their own formatting (e.g. pkg/errors) need the
+	// full fmt treatment, everything else can skip it
+	if _, ok := err.(fmt.Formatter); ok || err == nil {
+		_, werr := fmt.Fprintf(wr.Writer, "%s\n", err)
+		return werr
+	}
+
+	buf := lineBufPool.Get().(*[]byte)
+	*buf = append(append((*buf)[:0], err.Error()...), '\n')
+	_, werr := wr.Writer.Write(*buf)
+	lineBufPool.Put(buf)
+	return werr
+}
+
+var lineBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// A FallbackReporter tries each of its Reporters in order,
+// stopping at the first one that succeeds
+type FallbackReporter struct {
+	Reporters []ErrorReporterE
+
+	// OnSuccess, if set, is called with the index of the
+	// reporter that delivered the error
+	OnSuccess func(i int, er ErrorReporterE)
+}
+
+// Report sends the error down the chain of Reporters. If every
+// reporter fails, the error is dropped; use ReportE to find out.
+func (fr *FallbackReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = fr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE sends the error down the chain of Reporters, returning
+// the last failure if none of them succeed
+func (fr *FallbackReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	lastErr := errors.New("no reporters configured")
+	for i, er := range fr.Reporters {
+		lastErr = er.ReportE(ctx, err, metadata...)
+		if lastErr == nil {
+			if fr.OnSuccess != nil {
+				fr.OnSuccess(i, er)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("all reporters failed: %w", lastErr)
+}
+
+// Flush flushes all underlying Reporters
+func (fr *FallbackReporter) Flush(ctx context.Context) error {
+	var errs []error
+	for _, er := range fr.Reporters {
+		errs = append(errs, Flush(ctx, er))
 	}
-	fmt.Fprintf(wr.Writer, "%s\n", err)
+	return errors.Join(errs...)
 }
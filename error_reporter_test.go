@@ -0,0 +1,234 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+type stubReporter struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (sr *stubReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = sr.ReportE(ctx, err, metadata...)
+}
+
+func (sr *stubReporter) ReportE(_ context.Context, _ error, _ ...interface{}) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.calls++
+	return sr.err
+}
+
+func TestFallbackReporter(t *testing.T) {
+	first := &stubReporter{err: errors.New("bugsnag down")}
+	second := &stubReporter{err: errors.New("sentry down")}
+	third := &stubReporter{}
+	fourth := &stubReporter{}
+
+	succeeded := -1
+	fr := &FallbackReporter{
+		Reporters: []ErrorReporterE{first, second, third, fourth},
+		OnSuccess: func(i int, _ ErrorReporterE) { succeeded = i },
+	}
+
+	if err := fr.ReportE(context.Background(), errors.New("fallback test")); err != nil {
+		t.Fatalf("expected delivery, got %v", err)
+	}
+	if succeeded != 2 {
+		t.Errorf("expected reporter 2 to succeed, got %d", succeeded)
+	}
+	for i, sr := range []*stubReporter{first, second, third} {
+		if sr.calls != 1 {
+			t.Errorf("expected reporter %d to be called once, got %d", i, sr.calls)
+		}
+	}
+	if fourth.calls != 0 {
+		t.Errorf("expected reporter 3 not to be called, got %d", fourth.calls)
+	}
+}
+
+func TestFallbackReporterAllFail(t *testing.T) {
+	sentryDown := errors.New("sentry down")
+	fr := &FallbackReporter{
+		Reporters: []ErrorReporterE{
+			&stubReporter{err: errors.New("bugsnag down")},
+			&stubReporter{err: sentryDown},
+		},
+	}
+
+	err := fr.ReportE(context.Background(), errors.New("fallback test"))
+	if err == nil {
+		t.Fatal("expected an error when every reporter fails")
+	}
+	if !errors.Is(err, sentryDown) {
+		t.Errorf("expected the last failure to be wrapped, got %v", err)
+	}
+}
+
+type customFormatError struct{}
+
+func (customFormatError) Error() string { return "plain" }
+
+func (customFormatError) Format(s fmt.State, verb rune) {
+	fmt.Fprint(s, "formatted")
+}
+
+func TestWriterReporterOutput(t *testing.T) {
+	for _, err := range []error{
+		errors.New("plain error"),
+		fmt.Errorf("wrapped: %v", errors.New("inner")),
+		pkgerrors.New("pkg/errors error"),
+		pkgerrors.Wrap(errors.New("inner"), "pkg/errors wrap"),
+		customFormatError{},
+		nil,
+	} {
+		var got bytes.Buffer
+		wr := &WriterReporter{Writer: &got}
+		wr.Report(context.Background(), err)
+
+		want := fmt.Sprintf("%s\n", err)
+		if got.String() != want {
+			t.Errorf("expected %q, got %q", want, got.String())
+		}
+	}
+}
+
+func BenchmarkWriterReporter(b *testing.B) {
+	wr := &WriterReporter{Writer: ioutil.Discard}
+	err := errors.New("benchmark error")
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		wr.Report(ctx, err)
+	}
+}
+
+func BenchmarkWriterReporterFprintf(b *testing.B) {
+	err := errors.New("benchmark error")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fmt.Fprintf(ioutil.Discard, "%s\n", err)
+	}
+}
+
+func TestMultiReporterJoinsErrors(t *testing.T) {
+	bugsnagDown := errors.New("bugsnag down")
+	sentryDown := errors.New("sentry down")
+	ok := &stubReporter{}
+	var out bytes.Buffer
+
+	mr := &MultiReporter{
+		Reporters: []ErrorReporter{
+			&stubReporter{err: bugsnagDown},
+			ok,
+			&stubReporter{err: sentryDown},
+			&WriterReporter{Writer: &out},
+		},
+	}
+
+	err := mr.ReportE(context.Background(), errors.New("multi test"))
+	if !errors.Is(err, bugsnagDown) || !errors.Is(err, sentryDown) {
+		t.Fatalf("expected both failures to be joined, got %v", err)
+	}
+	if joined := err.(interface{ Unwrap() []error }).Unwrap(); len(joined) != 2 {
+		t.Errorf("expected exactly 2 failures, got %d", len(joined))
+	}
+	if ok.calls != 1 || out.String() != "multi test\n" {
+		t.Errorf("expected every reporter to be invoked")
+	}
+
+	if err := (&MultiReporter{Reporters: []ErrorReporter{ok}}).ReportE(context.Background(), errors.New("multi test")); err != nil {
+		t.Errorf("expected no error when every reporter succeeds, got %v", err)
+	}
+}
+
+func TestMultiReporterSharesMetadataSafely(t *testing.T) {
+	var seen []string
+	var mu sync.Mutex
+	reader := &TransformReporter{Transform: func(err error, metadata []interface{}) (error, []interface{}) {
+		md := metadataFrom(metadata)
+		for i := 0; i < 100; i++ {
+			mu.Lock()
+			seen = append(seen, md.ErrorClass+md.Severity)
+			mu.Unlock()
+		}
+		return nil, nil
+	}}
+
+	md := &BugsnagMetadata{Context: "checkout"}
+	mr := &MultiReporter{Reporters: []ErrorReporter{
+		&BugsnagReporter{APIKey: testAPIKey, Doer: &MockDoer{}},
+		reader,
+	}}
+	if err := mr.ReportE(context.Background(), errors.New("shared"), md); err != nil {
+		t.Fatal(err)
+	}
+
+	if md.ErrorClass != "" || md.Severity != "" {
+		t.Errorf("expected the caller's metadata to be left alone, got %+v", md)
+	}
+	for _, s := range seen {
+		if s != "" {
+			t.Fatalf("expected the other branch not to see bugsnag's defaults, got %q", s)
+		}
+	}
+}
+
+type flushingReporter struct {
+	stubReporter
+	flushes int
+}
+
+func (fr *flushingReporter) Flush(context.Context) error {
+	fr.flushes++
+	return nil
+}
+
+func TestFlushReachesNestedReporters(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	deep := &flushingReporter{}
+	throttled := &recordingReporter{}
+	root := &MultiReporter{
+		Reporters: []ErrorReporter{
+			WithDefaults(&ThrottleReporter{Reporter: throttled, Interval: time.Minute}, nil),
+			&SamplingReporter{Reporter: &FallbackReporter{
+				Reporters: []ErrorReporterE{deep},
+			}},
+			&WriterReporter{Writer: ioutil.Discard},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		root.Report(context.Background(), errors.New("repeated"))
+	}
+	if len(throttled.errs) != 1 {
+		t.Fatalf("expected repeats to be throttled, got %d reports", len(throttled.errs))
+	}
+
+	if err := Flush(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if deep.flushes != 1 {
+		t.Errorf("expected the nested reporter to be flushed once, got %d", deep.flushes)
+	}
+	if len(throttled.errs) != 2 || !strings.Contains(throttled.errs[1].Error(), "repeated 2 times") {
+		t.Errorf("expected the pending summary to be flushed, got %v", throttled.errs)
+	}
+}
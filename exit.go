@@ -0,0 +1,44 @@
+package bugsnack
+
+import (
+	"context"
+	"os"
+)
+
+// An ExitReporter passes errors on to Reporter, then exits the process
+// if their severity is in ExitOnSeverity. It is meant for CLI tools,
+// where e.g. a "critical" error should both be reported and end the
+// program with a non-zero exit code.
+type ExitReporter struct {
+	Reporter ErrorReporter
+
+	// ExitOnSeverity maps severities to the exit code to use
+	ExitOnSeverity map[string]int
+
+	// Exit defaults to os.Exit
+	Exit func(code int)
+}
+
+// Report sends the error on, then exits if its severity says so,
+// after flushing Reporter so that the error isn't lost in a buffer
+func (xr *ExitReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	xr.Reporter.Report(ctx, err, metadata...)
+
+	code, ok := xr.ExitOnSeverity[severity(metadataFrom(metadata))]
+	if !ok {
+		return
+	}
+
+	_ = Flush(ctx, xr.Reporter)
+
+	exit := xr.Exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(code)
+}
+
+// Flush flushes the underlying Reporter
+func (xr *ExitReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, xr.Reporter)
+}
@@ -0,0 +1,54 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExitReporter(t *testing.T) {
+	rr := &recordingReporter{}
+	var codes []int
+	xr := &ExitReporter{
+		Reporter:       rr,
+		ExitOnSeverity: map[string]int{"critical": 2, "error": 1},
+		Exit:           func(code int) { codes = append(codes, code) },
+	}
+	ctx := context.Background()
+
+	xr.Report(ctx, errors.New("warning"), &BugsnagMetadata{Severity: "warning"})
+	xr.Report(ctx, errors.New("info"), &BugsnagMetadata{Severity: "info"})
+	if len(codes) != 0 {
+		t.Fatalf("expected no exit for warnings and info, got %v", codes)
+	}
+
+	xr.Report(ctx, errors.New("critical"), &BugsnagMetadata{Severity: "critical"})
+	xr.Report(ctx, errors.New("no metadata"))
+	if len(codes) != 2 || codes[0] != 2 || codes[1] != 1 {
+		t.Errorf("expected exit codes [2 1], got %v", codes)
+	}
+	if len(rr.errs) != 4 {
+		t.Errorf("expected every error to be reported before exiting, got %d", len(rr.errs))
+	}
+}
+
+func TestExitReporterFlushes(t *testing.T) {
+	doer := &MockDoer{}
+	exited := false
+	xr := &ExitReporter{
+		Reporter:       &LokiReporter{Doer: doer, URL: "http://loki:3100/loki/api/v1/push", FlushInterval: time.Hour},
+		ExitOnSeverity: map[string]int{"critical": 2},
+		Exit: func(int) {
+			exited = true
+			if len(doer.Requests()) != 1 {
+				t.Errorf("expected the batch to be pushed before exiting, got %d pushes", len(doer.Requests()))
+			}
+		},
+	}
+
+	xr.Report(context.Background(), errors.New("disk gone"), &BugsnagMetadata{Severity: "critical"})
+	if !exited {
+		t.Error("expected to exit")
+	}
+}
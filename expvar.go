@@ -0,0 +1,62 @@
+package bugsnack
+
+import (
+	"context"
+	"expvar"
+	"sync"
+)
+
+// An ExpvarReporter counts errors in an expvar.Map before passing them
+// on, so that they show up on /debug/vars:
+//
+//	"bugsnack": {"total": 3, "byClass": {"*net.OpError": 2, "db.timeout": 1}}
+//
+// Errors are counted by their ErrorClass, falling back to their type.
+type ExpvarReporter struct {
+	Reporter ErrorReporter
+
+	// Name is the name the counters are published under, defaulting
+	// to "bugsnack". Reporters sharing a name share counters.
+	Name string
+
+	once    sync.Once
+	total   *expvar.Int
+	byClass *expvar.Map
+}
+
+var expvarMu sync.Mutex
+
+// Report counts the error and sends it on
+func (er *ExpvarReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	er.once.Do(er.publish)
+
+	class := errorClass(err, mergeMetadata(errorMetadata(err), metadataFrom(metadata)))
+
+	er.total.Add(1)
+	er.byClass.Add(class, 1)
+	er.Reporter.Report(ctx, err, metadata...)
+}
+
+// publish finds or creates the published counters
+func (er *ExpvarReporter) publish() {
+	name := er.Name
+	if name == "" {
+		name = "bugsnack"
+	}
+
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	m, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		m = expvar.NewMap(name)
+		m.Set("total", new(expvar.Int))
+		m.Set("byClass", new(expvar.Map).Init())
+	}
+	er.total = m.Get("total").(*expvar.Int)
+	er.byClass = m.Get("byClass").(*expvar.Map)
+}
+
+// Flush flushes the underlying Reporter
+func (er *ExpvarReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, er.Reporter)
+}
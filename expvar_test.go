@@ -0,0 +1,51 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+)
+
+type expvarCounters struct {
+	Total   int
+	ByClass map[string]int
+}
+
+func readExpvar(t *testing.T, name string) expvarCounters {
+	t.Helper()
+	var counters expvarCounters
+	if v := expvar.Get(name); v != nil {
+		if err := json.Unmarshal([]byte(v.String()), &counters); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return counters
+}
+
+func TestExpvarReporter(t *testing.T) {
+	// counters outlive the test when run repeatedly
+	before := readExpvar(t, "bugsnack_test")
+
+	inner := &stubReporter{}
+	er := &ExpvarReporter{Reporter: inner, Name: "bugsnack_test"}
+	ctx := context.Background()
+
+	er.Report(ctx, errors.New("timeout"), &BugsnagMetadata{ErrorClass: "db.timeout"})
+	er.Report(ctx, errors.New("timeout"), &BugsnagMetadata{ErrorClass: "db.timeout"})
+	er.Report(ctx, errors.New("not found"))
+
+	// another reporter with the same name shares the counters
+	(&ExpvarReporter{Reporter: inner, Name: "bugsnack_test"}).Report(ctx, errors.New("not found"))
+
+	after := readExpvar(t, "bugsnack_test")
+	if after.Total-before.Total != 4 ||
+		after.ByClass["db.timeout"]-before.ByClass["db.timeout"] != 2 ||
+		after.ByClass["*errors.errorString"]-before.ByClass["*errors.errorString"] != 2 {
+		t.Errorf("unexpected counters %+v (before %+v)", after, before)
+	}
+	if inner.calls != 4 {
+		t.Errorf("expected every report to be delegated, got %d", inner.calls)
+	}
+}
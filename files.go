@@ -0,0 +1,34 @@
+package bugsnack
+
+// A FileRef points at a file an error relates to, such as the input
+// of a job which failed, without carrying its content
+type FileRef struct {
+	Name        string
+	Size        int64
+	ContentType string
+
+	// URL optionally locates the file in storage
+	URL string
+}
+
+// WithFiles returns a copy of meta (which may be nil) with a "files"
+// tab describing each file by name. Files added by earlier calls are
+// kept.
+//
+//	er.Report(ctx, err, bugsnack.WithFiles(nil, bugsnack.FileRef{
+//	    Name: "orders.csv", Size: 5120, ContentType: "text/csv", URL: "s3://imports/orders.csv",
+//	}))
+func WithFiles(meta *BugsnagMetadata, files ...FileRef) *BugsnagMetadata {
+	tab := make(map[string]interface{}, len(files))
+	for _, f := range files {
+		ref := map[string]interface{}{
+			"size":        f.Size,
+			"contentType": f.ContentType,
+		}
+		if f.URL != "" {
+			ref["url"] = f.URL
+		}
+		tab[f.Name] = ref
+	}
+	return mergeMetadata(meta, &BugsnagMetadata{EventMetadata: &map[string]interface{}{"files": tab}})
+}
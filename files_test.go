@@ -0,0 +1,26 @@
+package bugsnack
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithFiles(t *testing.T) {
+	md := WithFiles(&BugsnagMetadata{Severity: "warning"},
+		FileRef{Name: "orders.csv", Size: 5120, ContentType: "text/csv", URL: "s3://imports/orders.csv"},
+		FileRef{Name: "logo.png", Size: 2048, ContentType: "image/png"},
+	)
+	md = WithFiles(md, FileRef{Name: "retry.csv", Size: 10, ContentType: "text/csv"})
+
+	if md.Severity != "warning" {
+		t.Errorf("expected the given metadata to be kept, got %+v", md)
+	}
+	want := map[string]interface{}{
+		"orders.csv": map[string]interface{}{"size": int64(5120), "contentType": "text/csv", "url": "s3://imports/orders.csv"},
+		"logo.png":   map[string]interface{}{"size": int64(2048), "contentType": "image/png"},
+		"retry.csv":  map[string]interface{}{"size": int64(10), "contentType": "text/csv"},
+	}
+	if got := (*md.EventMetadata)["files"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected files\n%v\ngot\n%v", want, got)
+	}
+}
@@ -0,0 +1,30 @@
+package bugsnack
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goroutineID returns the ID of the calling goroutine. Go does not
+// expose it officially, so it is parsed from the header of the
+// goroutine's stack trace; ok is false if that ever fails.
+func goroutineID() (id uint64, ok bool) {
+	var buf [64]byte
+	return parseGoroutineID(buf[:runtime.Stack(buf[:], false)])
+}
+
+// parseGoroutineID parses a stack header such as
+// "goroutine 18 [running]:"
+func parseGoroutineID(stack []byte) (uint64, bool) {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	end := bytes.IndexByte(stack, ' ')
+	if end < 0 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(string(stack[:end]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,53 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	id, ok := goroutineID()
+	if !ok || id == 0 {
+		t.Fatalf("expected a goroutine id, got %d (%v)", id, ok)
+	}
+
+	other := make(chan uint64)
+	go func() {
+		id, _ := goroutineID()
+		other <- id
+	}()
+	if otherID := <-other; otherID == id {
+		t.Errorf("expected a different id on another goroutine, got %d twice", id)
+	}
+}
+
+func TestParseGoroutineID(t *testing.T) {
+	for _, tc := range []struct {
+		stack string
+		id    uint64
+		ok    bool
+	}{
+		{"goroutine 18 [running]:\nmain.main()", 18, true},
+		{"goroutine 1 [running]:", 1, true},
+		{"goroutine x [running]:", 0, false},
+		{"goroutine", 0, false},
+		{"", 0, false},
+	} {
+		id, ok := parseGoroutineID([]byte(tc.stack))
+		if id != tc.id || ok != tc.ok {
+			t.Errorf("parseGoroutineID(%q) = %d, %v; expected %d, %v", tc.stack, id, ok, tc.id, tc.ok)
+		}
+	}
+}
+
+func TestThreadID(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), errors.New("thread id test"))
+
+	id, _ := goroutineID()
+	if threadID := sentEvent(t, doer, 0)["device"].(map[string]interface{})["threadId"]; threadID != float64(id) {
+		t.Errorf("expected threadId %d, got %v", id, threadID)
+	}
+}
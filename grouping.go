@@ -0,0 +1,77 @@
+package bugsnack
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"go/build"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// stackGroupingHash hashes the file and line of the first depth frames
+// of stack which are inProject, or returns "" if there are none, so
+// that errors raised at the same place group together whatever their
+// message and whatever library code they went through
+func stackGroupingHash(stack []stackFrame, depth int, inProject func(stackFrame) bool) string {
+	h := sha1.New()
+	n := 0
+	for _, f := range stack {
+		if n == depth {
+			break
+		}
+		if !inProject(f) {
+			continue
+		}
+		fmt.Fprintf(h, "%s:%d\n", f.File, f.Line)
+		n++
+	}
+	if n == 0 {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// frameGroupingHash returns the GroupingFrameDepth grouping hash of
+// err, or "" if that is disabled
+func (er *BugsnagReporter) frameGroupingHash(err error, pcs []uintptr) string {
+	if er.GroupingFrameDepth <= 0 {
+		return ""
+	}
+	return stackGroupingHash(errorStack(err, pcs), er.GroupingFrameDepth, er.inProject)
+}
+
+// inProject reports whether a frame belongs to the application rather
+// than a library: one of ProjectPackages if set, otherwise see
+// isProjectFrame
+func (er *BugsnagReporter) inProject(f stackFrame) bool {
+	if len(er.ProjectPackages) > 0 {
+		for _, pkg := range er.ProjectPackages {
+			if strings.HasPrefix(f.Function, pkg+".") || strings.HasPrefix(f.Function, pkg+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	return isProjectFrame(f)
+}
+
+// isProjectFrame reports whether a frame comes from outside the
+// standard library, the module cache and vendor directories
+func isProjectFrame(f stackFrame) bool {
+	file := filepath.ToSlash(f.File)
+	return !strings.HasPrefix(file, goroot) &&
+		!strings.Contains(file, "/pkg/mod/") &&
+		!strings.Contains(file, "/vendor/") &&
+		!isInternal(f)
+}
+
+// goroot is where standard library frames come from
+var goroot = func() string {
+	root := runtime.GOROOT()
+	if root == "" {
+		root = build.Default.GOROOT
+	}
+	return filepath.ToSlash(root) + "/"
+}()
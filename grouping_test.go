@@ -0,0 +1,70 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStackGroupingHash(t *testing.T) {
+	app := func(fn, file string, line int) stackFrame {
+		return stackFrame{Function: "example.com/app/" + fn, File: "/src/app/" + file, Line: line}
+	}
+	lib := func(fn string, line int) stackFrame {
+		return stackFrame{Function: "github.com/lib/pq." + fn, File: "/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go", Line: line}
+	}
+	er := &BugsnagReporter{ProjectPackages: []string{"example.com/app"}}
+
+	base := []stackFrame{lib("query", 10), app("store.Save", "store.go", 42), lib("exec", 20), app("handler.Post", "handler.go", 7), app("main.main", "main.go", 3)}
+	upgraded := []stackFrame{lib("queryContext", 99), lib("retry", 5), app("store.Save", "store.go", 42), app("handler.Post", "handler.go", 7), app("main.main", "main.go", 12)}
+	moved := []stackFrame{app("store.Save", "store.go", 43), app("handler.Post", "handler.go", 7)}
+
+	hash := stackGroupingHash(base, 2, er.inProject)
+	if hash == "" {
+		t.Fatal("expected a grouping hash")
+	}
+	if other := stackGroupingHash(upgraded, 2, er.inProject); other != hash {
+		t.Errorf("expected stacks differing in library frames and beyond the depth to group together, got %s and %s", hash, other)
+	}
+	if other := stackGroupingHash(moved, 2, er.inProject); other == hash {
+		t.Errorf("expected a different project frame to group apart")
+	}
+	if other := stackGroupingHash(base, 3, er.inProject); other == hash {
+		t.Errorf("expected the depth to change the grouping")
+	}
+	if other := stackGroupingHash([]stackFrame{lib("query", 10)}, 2, er.inProject); other != "" {
+		t.Errorf("expected no grouping hash without project frames, got %s", other)
+	}
+
+	byLocation := &BugsnagReporter{}
+	if byLocation.inProject(lib("query", 10)) || byLocation.inProject(stackFrame{Function: "fmt.Errorf", File: goroot + "src/fmt/errors.go"}) {
+		t.Errorf("expected module cache and standard library frames not to be in the project")
+	}
+	if !byLocation.inProject(app("store.Save", "store.go", 42)) {
+		t.Errorf("expected other frames to be in the project")
+	}
+}
+
+func TestGroupingFrameDepth(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, GroupingFrameDepth: 1}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		er.Report(ctx, WithReportMetadata(errors.New(fmt.Sprint("message ", i)), nil))
+	}
+	er.Report(ctx, errors.New("elsewhere"))
+	er.Report(ctx, errors.New("explicit"), &BugsnagMetadata{GroupingHash: "explicit"})
+
+	first, second, other := sentEvent(t, doer, 0)["groupingHash"], sentEvent(t, doer, 1)["groupingHash"], sentEvent(t, doer, 2)["groupingHash"]
+	if first == nil || first != second {
+		t.Errorf("expected reports from the same line to group together, got %v and %v", first, second)
+	}
+	if other == first {
+		t.Errorf("expected a report from another line to group apart")
+	}
+	if got := sentEvent(t, doer, 3)["groupingHash"]; got != "explicit" {
+		t.Errorf("expected an explicit GroupingHash to win, got %v", got)
+	}
+}
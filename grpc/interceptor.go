@@ -0,0 +1,118 @@
+// Package grpc provides gRPC server interceptors which report panics
+// and failed calls to a bugsnack.ErrorReporter. It builds against the
+// application's own google.golang.org/grpc, which bugsnack doesn't
+// vendor, so the interceptors fit the application's grpc.Server.
+//
+//	srv := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(bugsnackgrpc.UnaryServerInterceptor(er)),
+//		grpc.ChainStreamInterceptor(bugsnackgrpc.StreamServerInterceptor(er)),
+//	)
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fromatob/bugsnack"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// filteredMarker replaces the values of sensitive request metadata
+const filteredMarker = "[FILTERED]"
+
+// sensitiveKeys are substrings of metadata keys whose values are
+// never reported
+var sensitiveKeys = []string{"authorization", "cookie", "token", "secret", "password", "api-key", "apikey"}
+
+// UnaryServerInterceptor returns an interceptor which reports errors
+// returned by handlers to er, including non-OK statuses, along with
+// the method and the (scrubbed) request metadata. A panicking handler
+// is reported as unhandled and answered with codes.Internal.
+func UnaryServerInterceptor(er bugsnack.ErrorReporter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = reportPanic(ctx, er, info.FullMethod, r)
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		report(ctx, er, info.FullMethod, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor
+func StreamServerInterceptor(er bugsnack.ErrorReporter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := ss.Context()
+		defer func() {
+			if r := recover(); r != nil {
+				err = reportPanic(ctx, er, info.FullMethod, r)
+			}
+		}()
+
+		err = handler(srv, ss)
+		report(ctx, er, info.FullMethod, err)
+		return err
+	}
+}
+
+// reportPanic reports a recovered panic and returns the status sent
+// to the client in its place, which doesn't leak its details
+func reportPanic(ctx context.Context, er bugsnack.ErrorReporter, method string, recovered interface{}) error {
+	bugsnack.ReportPanic(ctx, bugsnack.WithDefaults(er, callMetadata(ctx, method, codes.Internal)), recovered)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// report sends err, if any, to er
+func report(ctx context.Context, er bugsnack.ErrorReporter, method string, err error) {
+	if err == nil {
+		return
+	}
+	er.Report(ctx, err, callMetadata(ctx, method, status.Code(err)))
+}
+
+// callMetadata describes the call in a "grpc" tab
+func callMetadata(ctx context.Context, method string, code codes.Code) *bugsnack.BugsnagMetadata {
+	tab := map[string]interface{}{
+		"method": method,
+		"code":   code.String(),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md) > 0 {
+		tab["metadata"] = scrubMetadata(md)
+	}
+	return &bugsnack.BugsnagMetadata{
+		Context:       method,
+		EventMetadata: &map[string]interface{}{"grpc": tab},
+	}
+}
+
+// scrubMetadata flattens md, replacing the values of sensitive keys
+// with filteredMarker
+func scrubMetadata(md metadata.MD) map[string]interface{} {
+	scrubbed := make(map[string]interface{}, len(md))
+	for k, values := range md {
+		if isSensitive(k) {
+			scrubbed[k] = filteredMarker
+			continue
+		}
+		scrubbed[k] = strings.Join(values, ", ")
+	}
+	return scrubbed
+}
+
+// isSensitive reports whether the metadata key k may carry credentials
+func isSensitive(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/fromatob/bugsnack"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// stringCodec lets the test service exchange plain strings, so it
+// needs no generated code
+type stringCodec struct{}
+
+func (stringCodec) Marshal(v interface{}) ([]byte, error) { return []byte(*v.(*string)), nil }
+
+func (stringCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*string) = string(data)
+	return nil
+}
+
+func (stringCodec) Name() string { return "string" }
+
+type reported struct {
+	err      error
+	metadata *bugsnack.BugsnagMetadata
+}
+
+type recordingReporter struct {
+	mu      sync.Mutex
+	reports []reported
+}
+
+func (rr *recordingReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	md, _ := metadata[0].(*bugsnack.BugsnagMetadata)
+	rr.reports = append(rr.reports, reported{err: err, metadata: md})
+}
+
+func (rr *recordingReporter) Reports() []reported {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return append([]reported(nil), rr.reports...)
+}
+
+// serve starts a test server with the interceptors reporting to er,
+// where each method behaves as handle says, and returns a connected
+// client
+func serve(t *testing.T, er bugsnack.ErrorReporter, handle func(method string) error) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(stringCodec{}),
+		grpc.UnaryInterceptor(UnaryServerInterceptor(er)),
+		grpc.StreamInterceptor(StreamServerInterceptor(er)),
+	)
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Service",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Panic", Handler: unaryHandler},
+			{MethodName: "Fail", Handler: unaryHandler},
+			{MethodName: "Echo", Handler: unaryHandler},
+		},
+		Streams: []grpc.StreamDesc{
+			{StreamName: "Watch", Handler: streamHandler, ServerStreams: true},
+		},
+	}, handle)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(stringCodec{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func unaryHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	var in string
+	if err := dec(&in); err != nil {
+		return nil, err
+	}
+	method, _ := grpc.Method(ctx)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if err := srv.(func(string) error)(method); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+	return interceptor(ctx, &in, &grpc.UnaryServerInfo{Server: srv, FullMethod: method}, handler)
+}
+
+func streamHandler(srv interface{}, ss grpc.ServerStream) error {
+	method, _ := grpc.Method(ss.Context())
+	return srv.(func(string) error)(method)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	rr := &recordingReporter{}
+	conn := serve(t, rr, func(method string) error {
+		switch method {
+		case "/test.Service/Panic":
+			panic("nil map")
+		case "/test.Service/Fail":
+			return status.Error(codes.Unavailable, "db down")
+		}
+		return nil
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"authorization", "Bearer s3cret",
+		"x-request-id", "abc123",
+	)
+	in, out := "ping", ""
+
+	if err := conn.Invoke(ctx, "/test.Service/Echo", &in, &out); err != nil || out != "ping" {
+		t.Fatalf("expected an echo, got %q, %v", out, err)
+	}
+
+	err := conn.Invoke(ctx, "/test.Service/Panic", &in, &out)
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected an Internal status, got %v", err)
+	}
+
+	err = conn.Invoke(ctx, "/test.Service/Fail", &in, &out)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected the handler's status, got %v", err)
+	}
+
+	reports := rr.Reports()
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+
+	panicked := reports[0]
+	if panicked.err.Error() != "panic: nil map" || !panicked.metadata.Unhandled {
+		t.Errorf("expected an unhandled panic, got %v %+v", panicked.err, panicked.metadata)
+	}
+	tab := (*panicked.metadata.EventMetadata)["grpc"].(map[string]interface{})
+	if tab["method"] != "/test.Service/Panic" || tab["code"] != "Internal" {
+		t.Errorf("expected the method and code, got %v", tab)
+	}
+	md := tab["metadata"].(map[string]interface{})
+	if md["authorization"] != filteredMarker || md["x-request-id"] != "abc123" {
+		t.Errorf("expected scrubbed request metadata, got %v", md)
+	}
+
+	failed := reports[1]
+	if status.Code(failed.err) != codes.Unavailable || failed.metadata.Context != "/test.Service/Fail" {
+		t.Errorf("expected the failed call, got %v %+v", failed.err, failed.metadata)
+	}
+	if code := (*failed.metadata.EventMetadata)["grpc"].(map[string]interface{})["code"]; code != "Unavailable" {
+		t.Errorf("expected code Unavailable, got %v", code)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	rr := &recordingReporter{}
+	conn := serve(t, rr, func(method string) error {
+		panic(errors.New("closed channel"))
+	})
+
+	desc := &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+	stream, err := conn.NewStream(context.Background(), desc, "/test.Service/Watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, out := "watch", ""
+	if err := stream.SendMsg(&in); err != nil {
+		t.Fatal(err)
+	}
+	stream.CloseSend()
+
+	if err := stream.RecvMsg(&out); status.Code(err) != codes.Internal {
+		t.Errorf("expected an Internal status, got %v", err)
+	}
+
+	reports := rr.Reports()
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+	r := reports[0]
+	if r.err.Error() != "closed channel" || !r.metadata.Unhandled {
+		t.Errorf("expected the panic's error, got %v %+v", r.err, r.metadata)
+	}
+	if method := (*r.metadata.EventMetadata)["grpc"].(map[string]interface{})["method"]; method != "/test.Service/Watch" {
+		t.Errorf("expected the method, got %v", method)
+	}
+}
@@ -0,0 +1,53 @@
+package bugsnack
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// An ErrorMatcher recognizes a kind of error, either a sentinel error
+// (see MatchIs) or an error type (see MatchType), anywhere in an
+// error's chain
+type ErrorMatcher struct {
+	is  error
+	typ reflect.Type
+}
+
+// MatchIs matches errors for which errors.Is(err, target) holds
+func MatchIs(target error) ErrorMatcher {
+	return ErrorMatcher{is: target}
+}
+
+// MatchType matches errors of the same type as example, e.g.
+// MatchType(&net.OpError{})
+func MatchType(example error) ErrorMatcher {
+	return ErrorMatcher{typ: reflect.TypeOf(example)}
+}
+
+// Matches reports whether err is matched. Unlike errors.Is alone,
+// this also sees through pkg/errors wrapping.
+func (m ErrorMatcher) Matches(err error) bool {
+	for e := err; e != nil; e = unwrap(e) {
+		if m.is != nil && errors.Is(e, m.is) {
+			return true
+		}
+		if m.typ != nil && reflect.TypeOf(e) == m.typ {
+			return true
+		}
+	}
+	return false
+}
+
+// hint returns the hints matching err, joined together
+func hint(hints map[ErrorMatcher]string, err error) string {
+	var matched []string
+	for m, h := range hints {
+		if m.Matches(err) {
+			matched = append(matched, h)
+		}
+	}
+	sort.Strings(matched)
+	return strings.Join(matched, "; ")
+}
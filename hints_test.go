@@ -0,0 +1,38 @@
+package bugsnack
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestHints(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   doer,
+		Hints: map[ErrorMatcher]string{
+			MatchIs(sql.ErrConnDone):     "check the DB connection pool",
+			MatchType(&net.OpError{}):    "check the network",
+			MatchIs(context.Canceled):    "the client went away",
+			MatchIs(errors.New("other")): "never matches",
+		},
+	}
+	ctx := context.Background()
+
+	er.Report(ctx, fmt.Errorf("saving order: %w", sql.ErrConnDone))
+	er.Report(ctx, pkgerrors.Wrap(&net.OpError{Op: "dial", Err: errors.New("refused")}, "calling payments"))
+	er.Report(ctx, errors.New("unknown"))
+
+	for i, want := range []string{"check the DB connection pool", "check the network", ""} {
+		tab := sentEvent(t, doer, i)["metaData"].(map[string]interface{})["error"].(map[string]interface{})
+		if h, _ := tab["hint"].(string); h != want {
+			t.Errorf("report %d: expected hint %q, got %q", i, want, h)
+		}
+	}
+}
@@ -1,9 +1,107 @@
 package bugsnack
 
-import "net/http"
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
 
 // Doer is a helper interface used within bugsnack
 // to avoid requiring an *http.Client be passed around
 type Doer interface {
 	Do(*http.Request) (*http.Response, error)
 }
+
+// defaultDoer is used by reporters that were not given a Doer
+var defaultDoer Doer = http.DefaultClient
+
+// HTTPDoerConfig configures the client built by NewHTTPDoer
+type HTTPDoerConfig struct {
+	// TLSConfig is used for https connections, e.g. to trust a
+	// private root CA or to present a client certificate (mTLS)
+	TLSConfig *tls.Config
+
+	// Proxy selects the proxy for each request, defaulting to
+	// http.ProxyFromEnvironment
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// Timeout bounds each request, including reading the response
+	Timeout time.Duration
+
+	// DialTimeout and TLSHandshakeTimeout bound connection setup
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+}
+
+// NewHTTPDoer builds an *http.Client from cfg, sparing users from
+// hand-rolling a transport for proxies or custom TLS
+func NewHTTPDoer(cfg HTTPDoerConfig) Doer {
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: newTransport(cfg),
+	}
+}
+
+func newTransport(cfg HTTPDoerConfig) *http.Transport {
+	proxy := cfg.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	return &http.Transport{
+		Proxy:               proxy,
+		DialContext:         (&net.Dialer{Timeout: cfg.DialTimeout}).DialContext,
+		TLSClientConfig:     cfg.TLSConfig,
+		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
+	}
+}
+
+// PooledDoerConfig configures the client built by NewPooledDoer
+type PooledDoerConfig struct {
+	HTTPDoerConfig
+
+	// MaxIdleConnsPerHost is how many idle connections are kept open
+	// to the notify endpoint, defaulting to 16. It should be about the
+	// number of reports expected to be in flight at once.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept open,
+	// defaulting to 90 seconds
+	IdleConnTimeout time.Duration
+}
+
+// NewPooledDoer builds an *http.Client suited to sending many reports
+// to a single endpoint: connections are kept alive and reused, and
+// HTTP/2 is used where the server supports it. Unless set, Timeout
+// defaults to 10 seconds, and DialTimeout and TLSHandshakeTimeout to
+// 5 seconds.
+func NewPooledDoer(cfg PooledDoerConfig) Doer {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.TLSHandshakeTimeout == 0 {
+		cfg.TLSHandshakeTimeout = 5 * time.Second
+	}
+	if cfg.MaxIdleConnsPerHost == 0 {
+		cfg.MaxIdleConnsPerHost = 16
+	}
+	if cfg.IdleConnTimeout == 0 {
+		cfg.IdleConnTimeout = 90 * time.Second
+	}
+
+	transport := newTransport(cfg.HTTPDoerConfig)
+	transport.MaxIdleConns = cfg.MaxIdleConnsPerHost
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+	transport.ForceAttemptHTTP2 = true
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,75 @@
+package bugsnack
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPDoerRootCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	untrusted := NewHTTPDoer(HTTPDoerConfig{Timeout: 5 * time.Second})
+	if _, err := untrusted.Do(req); err == nil {
+		t.Error("expected an unknown authority error without the custom root CA")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	trusted := NewHTTPDoer(HTTPDoerConfig{
+		TLSConfig: &tls.Config{RootCAs: pool},
+		Timeout:   5 * time.Second,
+	})
+	resp, err := trusted.Do(req)
+	if err != nil {
+		t.Fatalf("expected the custom root CA to be trusted, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestNewPooledDoerReusesConnections(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	er := &BugsnagReporter{
+		APIKey:   testAPIKey,
+		Doer:     NewPooledDoer(PooledDoerConfig{}),
+		Endpoint: srv.URL,
+	}
+	for i := 0; i < 50; i++ {
+		if err := er.ReportE(context.Background(), errors.New("pooled")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("expected 50 reports to share 1 connection, got %d", n)
+	}
+}
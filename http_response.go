@@ -0,0 +1,65 @@
+package bugsnack
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// upstreamHeaders are the response headers WithHTTPResponse keeps
+var upstreamHeaders = []string{"Content-Type", "Content-Length", "Retry-After", "X-Request-Id"}
+
+// WithHTTPResponse returns a copy of meta (which may be nil) with an
+// "upstream" tab describing resp: the request's method and URL
+// (without credentials or query, which may hold secrets), the status,
+// a few telling headers and up to bodyLimit bytes of the body. The
+// body is put back, so the caller can still read all of it.
+//
+//	if resp.StatusCode >= 500 {
+//	    er.Report(ctx, err, bugsnack.WithHTTPResponse(nil, resp, 1024))
+//	}
+func WithHTTPResponse(meta *BugsnagMetadata, resp *http.Response, bodyLimit int) *BugsnagMetadata {
+	tab := map[string]interface{}{
+		"status": resp.StatusCode,
+	}
+	if req := resp.Request; req != nil {
+		tab["method"] = req.Method
+		if req.URL != nil {
+			u := *req.URL
+			u.User, u.RawQuery, u.ForceQuery = nil, "", false
+			tab["url"] = u.String()
+		}
+	}
+
+	headers := map[string]interface{}{}
+	for _, name := range upstreamHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	if len(headers) > 0 {
+		tab["headers"] = headers
+	}
+
+	if resp.Body != nil && bodyLimit > 0 {
+		// one byte more tells whether the body was cut short
+		peeked, err := io.ReadAll(io.LimitReader(resp.Body, int64(bodyLimit)+1))
+		resp.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(peeked), resp.Body), Closer: resp.Body}
+		if err != nil {
+			tab["bodyError"] = err.Error()
+		}
+		if len(peeked) > bodyLimit {
+			peeked = peeked[:bodyLimit]
+			tab["bodyTruncated"] = true
+		}
+		tab["body"] = string(peeked)
+	}
+
+	return mergeMetadata(meta, &BugsnagMetadata{EventMetadata: &map[string]interface{}{"upstream": tab}})
+}
+
+// peekedBody replays what was read from a body before the rest of it
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
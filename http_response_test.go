@@ -0,0 +1,68 @@
+package bugsnack
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithHTTPResponse(t *testing.T) {
+	body := strings.Repeat("upstream error ", 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Retry-After", "30")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusBadGateway)
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/orders?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	md := WithHTTPResponse(&BugsnagMetadata{Severity: "warning"}, resp, 20)
+	if md.Severity != "warning" {
+		t.Errorf("expected the given metadata to be kept, got %+v", md)
+	}
+	tab := (*md.EventMetadata)["upstream"].(map[string]interface{})
+	want := map[string]interface{}{
+		"method":        http.MethodGet,
+		"url":           srv.URL + "/orders",
+		"status":        http.StatusBadGateway,
+		"headers":       map[string]interface{}{"Content-Type": "text/plain", "Content-Length": "150", "Retry-After": "30"},
+		"body":          body[:20],
+		"bodyTruncated": true,
+	}
+	if !reflect.DeepEqual(tab, want) {
+		t.Errorf("expected\n%v\ngot\n%v", want, tab)
+	}
+
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != body {
+		t.Errorf("expected the caller to still read the whole body, got %q", rest)
+	}
+}
+
+func TestWithHTTPResponseShortBody(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("oops")),
+	}
+	tab := (*WithHTTPResponse(nil, resp, 20).EventMetadata)["upstream"].(map[string]interface{})
+	if tab["body"] != "oops" || tab["bodyTruncated"] != nil {
+		t.Errorf("expected the whole body without a truncation flag, got %v", tab)
+	}
+	if rest, _ := io.ReadAll(resp.Body); string(rest) != "oops" {
+		t.Errorf("expected the caller to still read the body, got %q", rest)
+	}
+}
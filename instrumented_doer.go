@@ -0,0 +1,70 @@
+package bugsnack
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// An InstrumentedDoer counts the requests going through Doer, by
+// status code, and how long they took, so that the health of error
+// reporting can be watched apart from an application's own traffic
+type InstrumentedDoer struct {
+	Doer Doer
+
+	mu    sync.Mutex
+	stats DoerStats
+}
+
+// DoerStats are the counters kept by an InstrumentedDoer. Requests
+// which failed without a response are counted in Errors rather than
+// StatusCodes.
+type DoerStats struct {
+	Requests     int
+	Errors       int
+	StatusCodes  map[int]int
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// Do sends req through the underlying Doer, recording the outcome
+func (id *InstrumentedDoer) Do(req *http.Request) (*http.Response, error) {
+	doer := id.Doer
+	if doer == nil {
+		doer = defaultDoer
+	}
+
+	start := now()
+	resp, err := doer.Do(req)
+	latency := now().Sub(start)
+
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	id.stats.Requests++
+	id.stats.TotalLatency += latency
+	if latency > id.stats.MaxLatency {
+		id.stats.MaxLatency = latency
+	}
+	if err != nil {
+		id.stats.Errors++
+	} else {
+		if id.stats.StatusCodes == nil {
+			id.stats.StatusCodes = map[int]int{}
+		}
+		id.stats.StatusCodes[resp.StatusCode]++
+	}
+	return resp, err
+}
+
+// Stats returns a copy of the counters so far
+func (id *InstrumentedDoer) Stats() DoerStats {
+	id.mu.Lock()
+	defer id.mu.Unlock()
+
+	stats := id.stats
+	stats.StatusCodes = make(map[int]int, len(id.stats.StatusCodes))
+	for code, n := range id.stats.StatusCodes {
+		stats.StatusCodes[code] = n
+	}
+	return stats
+}
@@ -0,0 +1,63 @@
+package bugsnack
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// slowDoer advances the stubbed clock before answering
+type slowDoer struct {
+	Doer
+	clock  *time.Time
+	delays []time.Duration
+}
+
+func (sd *slowDoer) Do(req *http.Request) (*http.Response, error) {
+	*sd.clock = sd.clock.Add(sd.delays[0])
+	sd.delays = sd.delays[1:]
+	return sd.Doer.Do(req)
+}
+
+func TestInstrumentedDoer(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	mock := (&MockDoer{}).
+		RespondWith(http.StatusOK, "").
+		RespondWith(http.StatusTooManyRequests, "").
+		FailWith(errors.New("connection reset")).
+		RespondWith(http.StatusOK, "")
+	id := &InstrumentedDoer{Doer: &slowDoer{
+		Doer:   mock,
+		clock:  &clock,
+		delays: []time.Duration{100 * time.Millisecond, 50 * time.Millisecond, 2 * time.Second, 150 * time.Millisecond},
+	}}
+
+	for i := 0; i < 4; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "https://notify.bugsnag.com", nil)
+		if resp, err := id.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	stats := id.Stats()
+	want := DoerStats{
+		Requests:     4,
+		Errors:       1,
+		StatusCodes:  map[int]int{http.StatusOK: 2, http.StatusTooManyRequests: 1},
+		TotalLatency: 2300 * time.Millisecond,
+		MaxLatency:   2 * time.Second,
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("expected %+v, got %+v", want, stats)
+	}
+
+	stats.StatusCodes[http.StatusOK] = 100
+	if id.Stats().StatusCodes[http.StatusOK] != 2 {
+		t.Errorf("expected Stats to return a copy")
+	}
+}
@@ -0,0 +1,140 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// jiraSummaryLimit is the longest summary Jira accepts
+const jiraSummaryLimit = 255
+
+// A JiraReporter files a Jira issue for the first occurrence of each
+// group of errors (going by GroupingHash, or else the message), and
+// comments on that issue when the group occurs again. Which issue
+// belongs to which group is only remembered in memory, so a restarted
+// process files new issues.
+type JiraReporter struct {
+	// Doer sends the requests, defaulting to http.DefaultClient
+	Doer Doer
+
+	// BaseURL is the address of the Jira site, e.g.
+	// https://example.atlassian.net
+	BaseURL string
+
+	// Email and APIToken authenticate requests
+	Email    string
+	APIToken string
+
+	Project string
+
+	// IssueType defaults to Bug
+	IssueType string
+
+	// mu is held while reporting, so that concurrent first
+	// occurrences of a group do not file an issue each
+	mu     sync.Mutex
+	issues map[string]string
+}
+
+// Report files or comments on an issue, ignoring any failure
+func (jr *JiraReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = jr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE files or comments on an issue, returning any error
+// encountered. Nil and private errors are skipped.
+func (jr *JiraReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	if err == nil || isPrivate(err, metadata) {
+		return nil
+	}
+
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+	md.populateMetadata(err)
+	key := groupingKey(err, md)
+
+	var pcs []uintptr
+	if !hasStack(err) {
+		pcs = callers(1)
+	}
+
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+
+	if issue, ok := jr.issues[key]; ok {
+		comment := map[string]interface{}{
+			"body": fmt.Sprintf("Occurred again at %s:\n%s", now().UTC().Format(eventTimeFormat), err),
+		}
+		return jr.post(ctx, "/rest/api/2/issue/"+issue+"/comment", comment, nil)
+	}
+
+	issueType := jr.IssueType
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	description := fmt.Sprintf("%s\n\nClass: %s\nSeverity: %s\n", err, md.ErrorClass, md.Severity)
+	if md.Context != "" {
+		description += "Context: " + md.Context + "\n"
+	}
+	description += "\n{noformat}\n" + stackString(errorStack(err, pcs)) + "\n{noformat}"
+
+	issue := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]interface{}{"key": jr.Project},
+			"issuetype":   map[string]interface{}{"name": issueType},
+			"summary":     truncateMessage(strings.SplitN(err.Error(), "\n", 2)[0], jiraSummaryLimit),
+			"description": description,
+		},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := jr.post(ctx, "/rest/api/2/issue", issue, &created); err != nil {
+		return err
+	}
+	if jr.issues == nil {
+		jr.issues = map[string]string{}
+	}
+	jr.issues[key] = created.Key
+	return nil
+}
+
+// post sends v as JSON to path, decoding the response into out
+// unless it is nil
+func (jr *JiraReporter) post(ctx context.Context, path string, v, out interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(jr.BaseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(jr.Email, jr.APIToken)
+
+	doer := jr.Doer
+	if doer == nil {
+		doer = defaultDoer
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, defaultResponseReadLimit))
+		return fmt.Errorf("could not report to jira: %s: %s", resp.Status, respBody)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, defaultResponseReadLimit)).Decode(out)
+}
@@ -0,0 +1,104 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJiraReporter(t *testing.T) {
+	doer := (&MockDoer{}).
+		RespondWith(http.StatusCreated, `{"id":"10001","key":"OPS-1"}`).
+		RespondWith(http.StatusCreated, `{"id":"1"}`).
+		RespondWith(http.StatusCreated, `{"id":"10002","key":"OPS-2"}`).
+		RespondWith(http.StatusCreated, `{"id":"2"}`)
+	jr := &JiraReporter{
+		Doer:     doer,
+		BaseURL:  "https://example.atlassian.net/",
+		Email:    "bot@example.com",
+		APIToken: "token",
+		Project:  "OPS",
+	}
+	ctx := context.Background()
+
+	for _, err := range []error{
+		errors.New("payment failed"),
+		errors.New("payment failed"),
+		errors.New("disk full"),
+		WithReportMetadata(errors.New("payment failed for order 7"), &BugsnagMetadata{GroupingHash: "payment failed"}),
+	} {
+		if err := jr.ReportE(ctx, err, &BugsnagMetadata{Severity: "warning"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	requests, bodies := doer.Requests(), doer.Bodies()
+	wantPaths := []string{
+		"/rest/api/2/issue",
+		"/rest/api/2/issue/OPS-1/comment",
+		"/rest/api/2/issue",
+		"/rest/api/2/issue/OPS-1/comment",
+	}
+	if len(requests) != len(wantPaths) {
+		t.Fatalf("expected %d requests, got %d", len(wantPaths), len(requests))
+	}
+	for i, want := range wantPaths {
+		if requests[i].Method != http.MethodPost || requests[i].URL.Path != want {
+			t.Errorf("request %d: expected POST %s, got %s %s", i, want, requests[i].Method, requests[i].URL.Path)
+		}
+		if user, pass, ok := requests[i].BasicAuth(); !ok || user != "bot@example.com" || pass != "token" {
+			t.Errorf("request %d: expected basic auth, got %q %q", i, user, pass)
+		}
+	}
+
+	var issue struct {
+		Fields struct {
+			Project     struct{ Key string }
+			IssueType   struct{ Name string }
+			Summary     string
+			Description string
+		}
+	}
+	if err := json.Unmarshal(bodies[0], &issue); err != nil {
+		t.Fatal(err)
+	}
+	fields := issue.Fields
+	if fields.Project.Key != "OPS" || fields.IssueType.Name != "Bug" || fields.Summary != "payment failed" {
+		t.Errorf("unexpected issue %+v", fields)
+	}
+	if !strings.Contains(fields.Description, "Severity: warning") || !strings.Contains(fields.Description, "TestJiraReporter") {
+		t.Errorf("expected the description to hold the details and stack, got %q", fields.Description)
+	}
+
+	var comment struct{ Body string }
+	if err := json.Unmarshal(bodies[3], &comment); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(comment.Body, "payment failed for order 7") {
+		t.Errorf("expected the comment to hold the message, got %q", comment.Body)
+	}
+}
+
+func TestJiraReporterErrors(t *testing.T) {
+	doer := (&MockDoer{}).
+		RespondWith(http.StatusBadRequest, `{"errorMessages":["project is required"]}`).
+		RespondWith(http.StatusCreated, `{"key":"OPS-1"}`)
+	jr := &JiraReporter{Doer: doer, BaseURL: "https://example.atlassian.net"}
+
+	if err := jr.ReportE(context.Background(), errors.New("boom")); err == nil || !strings.Contains(err.Error(), "project is required") {
+		t.Errorf("expected the rejection, got %v", err)
+	}
+	if err := jr.ReportE(context.Background(), errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	if path := doer.Requests()[1].URL.Path; path != "/rest/api/2/issue" {
+		t.Errorf("expected a failed creation to be retried next time, got %s", path)
+	}
+
+	if err := jr.ReportE(context.Background(), nil); err != nil || len(doer.Requests()) != 2 {
+		t.Errorf("expected a nil error to be skipped, got %v after %d requests", err, len(doer.Requests()))
+	}
+}
@@ -0,0 +1,21 @@
+package bugsnack
+
+// joinedLeaves returns the errors joined (e.g. by errors.Join)
+// somewhere in err's chain, with nested joins flattened, or
+// just err if nothing is joined
+func joinedLeaves(err error) []error {
+	for e := err; e != nil; e = unwrap(e) {
+		joined, ok := e.(interface{ Unwrap() []error })
+		if !ok {
+			continue
+		}
+		var leaves []error
+		for _, child := range joined.Unwrap() {
+			if child != nil {
+				leaves = append(leaves, joinedLeaves(child)...)
+			}
+		}
+		return leaves
+	}
+	return []error{err}
+}
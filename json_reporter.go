@@ -0,0 +1,96 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// A JSONReporter writes each error to Writer as a single line of JSON
+// with the fields common log schemas expect (level, msg, error, stack
+// and time), plus context, groupingHash and metaData when there are
+// any. Lines are written whole, even when reporting concurrently.
+type JSONReporter struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+// StdoutJSONReporter returns a JSONReporter writing to stdout, to be
+// picked up by a container platform's log collector
+func StdoutJSONReporter() *JSONReporter {
+	return &JSONReporter{Writer: os.Stdout}
+}
+
+type jsonLine struct {
+	Level        string                  `json:"level"`
+	Msg          string                  `json:"msg"`
+	Error        string                  `json:"error"`
+	Stack        string                  `json:"stack,omitempty"`
+	Time         string                  `json:"time"`
+	Context      string                  `json:"context,omitempty"`
+	GroupingHash string                  `json:"groupingHash,omitempty"`
+	MetaData     *map[string]interface{} `json:"metaData,omitempty"`
+}
+
+// Report writes the error as a line of JSON
+func (jr *JSONReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = jr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE behaves like Report, but returns any error encountered
+// while encoding or writing
+func (jr *JSONReporter) ReportE(_ context.Context, err error, metadata ...interface{}) error {
+	if jr.Writer == nil || err == nil {
+		return nil
+	}
+
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+	line := jsonLine{
+		Level:        logLevel(severity(md)),
+		Msg:          err.Error(),
+		Error:        errorClass(err, md),
+		Stack:        stackString(errorStack(err, callers(1))),
+		Time:         now().UTC().Format(eventTimeFormat),
+		Context:      md.Context,
+		GroupingHash: md.GroupingHash,
+	}
+	if !IsZeroInterface(md.EventMetadata) {
+		resolved := resolveThunks(*md.EventMetadata).(map[string]interface{})
+		line.MetaData = &resolved
+	}
+
+	b, encodeErr := encodeJSON(line)
+	if encodeErr != nil {
+		line.MetaData = &map[string]interface{}{"metaDataDropped": encodeErr.Error()}
+		if b, encodeErr = encodeJSON(line); encodeErr != nil {
+			return encodeErr
+		}
+	}
+
+	// a single Write per line, so that lines never interleave
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	_, werr := jr.Writer.Write(b)
+	return werr
+}
+
+// logLevel maps a bugsnag severity to the usual log level names
+func logLevel(severity string) string {
+	if severity == "warning" {
+		return "warn"
+	}
+	return severity
+}
+
+// stackString renders a stack one "function file:line" per line
+func stackString(stack []stackFrame) string {
+	lines := make([]string, len(stack))
+	for i, f := range stack {
+		lines[i] = fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package bugsnack
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONReporter(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC) }
+
+	var buf bytes.Buffer
+	jr := &JSONReporter{Writer: &buf}
+	jr.Report(context.Background(), errors.New("disk full"), &BugsnagMetadata{
+		Severity:      "warning",
+		Context:       "uploader",
+		EventMetadata: &map[string]interface{}{"disk": map[string]interface{}{"free": 0}},
+	})
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a line of JSON, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]interface{}{
+		"level":   "warn",
+		"msg":     "disk full",
+		"error":   "*errors.errorString",
+		"time":    "2017-05-05T12:00:00.000Z",
+		"context": "uploader",
+	} {
+		if line[key] != want {
+			t.Errorf("expected %s to be %v, got %v", key, want, line[key])
+		}
+	}
+	if stack, _ := line["stack"].(string); !strings.Contains(stack, "TestJSONReporter") {
+		t.Errorf("expected the stack to start at the test, got %q", stack)
+	}
+	if _, ok := line["metaData"].(map[string]interface{})["disk"]; !ok {
+		t.Errorf("expected the metadata to be included, got %v", line["metaData"])
+	}
+	if !strings.HasSuffix(buf.String(), "}\n") || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("expected a single line, got %q", buf.String())
+	}
+}
+
+// chunkedWriter writes a byte at a time, so that any interleaving of
+// concurrent writes shows up
+type chunkedWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (cw *chunkedWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		cw.mu.Lock()
+		cw.buf.WriteByte(b)
+		cw.mu.Unlock()
+	}
+	return len(p), nil
+}
+
+func TestJSONReporterConcurrency(t *testing.T) {
+	w := &chunkedWriter{}
+	jr := &JSONReporter{Writer: w}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				jr.Report(context.Background(), fmt.Errorf("error %d.%d", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := 0
+	scanner := bufio.NewScanner(&w.buf)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("expected whole lines of JSON, got %q: %v", scanner.Text(), err)
+		}
+		lines++
+	}
+	if lines != 200 {
+		t.Errorf("expected 200 lines, got %d", lines)
+	}
+}
@@ -0,0 +1,167 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLeakInterval = time.Minute
+	defaultLeakWindow   = 10 * time.Minute
+)
+
+// A LeakDetector samples the number of goroutines every Interval, and
+// reports a possible goroutine leak when it grows by more than
+// Threshold within Window. The report carries the goroutines grouped
+// by stack under a "goroutines" tab, so the leaking one stands out.
+//
+//	ld := &bugsnack.LeakDetector{Reporter: er, Threshold: 1000}
+//	ld.Start()
+//	defer ld.Stop()
+type LeakDetector struct {
+	Reporter  ErrorReporter
+	Threshold int
+
+	// Interval defaults to a minute and Window to ten minutes
+	Interval time.Duration
+	Window   time.Duration
+
+	// Count returns the number of goroutines, defaulting to
+	// runtime.NumGoroutine
+	Count func() int
+
+	mu      sync.Mutex
+	samples []leakSample
+	timer   interface{ Stop() bool }
+	stopped bool
+}
+
+type leakSample struct {
+	at    time.Time
+	count int
+}
+
+// Start starts sampling in the background
+func (ld *LeakDetector) Start() {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.stopped = false
+	ld.timer = afterFunc(ld.interval(), ld.tick)
+}
+
+// Stop stops sampling
+func (ld *LeakDetector) Stop() {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.stopped = true
+	if ld.timer != nil {
+		ld.timer.Stop()
+	}
+}
+
+func (ld *LeakDetector) tick() {
+	ld.Sample(context.Background())
+
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	if !ld.stopped {
+		ld.timer = afterFunc(ld.interval(), ld.tick)
+	}
+}
+
+// Sample takes a single sample, reporting if the goroutines have
+// grown too much. It is called by the background sampling, but may
+// be called directly instead, e.g. from an existing ticker.
+func (ld *LeakDetector) Sample(ctx context.Context) {
+	count := runtime.NumGoroutine
+	if ld.Count != nil {
+		count = ld.Count
+	}
+	current := leakSample{at: now(), count: count()}
+
+	ld.mu.Lock()
+	window := ld.Window
+	if window <= 0 {
+		window = defaultLeakWindow
+	}
+	for len(ld.samples) > 0 && current.at.Sub(ld.samples[0].at) > window {
+		ld.samples = ld.samples[1:]
+	}
+	ld.samples = append(ld.samples, current)
+	baseline := ld.samples[0]
+	leaking := current.count-baseline.count > ld.Threshold
+	if leaking {
+		// start over, so the same growth is not reported again
+		ld.samples = []leakSample{current}
+	}
+	ld.mu.Unlock()
+
+	if !leaking {
+		return
+	}
+	err := fmt.Errorf("possible goroutine leak: %d goroutines, up from %d in %s",
+		current.count, baseline.count, current.at.Sub(baseline.at))
+	ld.Reporter.Report(ctx, err, &BugsnagMetadata{
+		ErrorClass:   "bugsnack.LeakDetector",
+		GroupingHash: "bugsnack.LeakDetector",
+		Severity:     "warning",
+		EventMetadata: &map[string]interface{}{
+			"goroutines": map[string]interface{}{
+				"count":    current.count,
+				"baseline": baseline.count,
+				"dump":     groupGoroutines(goroutineDump()),
+			},
+		},
+	})
+}
+
+func (ld *LeakDetector) interval() time.Duration {
+	if ld.Interval <= 0 {
+		return defaultLeakInterval
+	}
+	return ld.Interval
+}
+
+// goroutineArgs matches the argument values in a stack dump, which
+// differ between otherwise identical goroutines
+var goroutineArgs = regexp.MustCompile(`\((0x[0-9a-f]+|\.\.\.)(, (0x[0-9a-f]+|\.\.\.|\{[^)]*\}))*\)`)
+
+// groupGoroutines condenses a dump of all goroutines by grouping those
+// with the same state and stack, largest groups first
+func groupGoroutines(dump string) string {
+	counts := map[string]int{}
+	for _, block := range strings.Split(strings.TrimSpace(dump), "\n\n") {
+		header, stack, _ := strings.Cut(block, "\n")
+		state := ""
+		if i := strings.Index(header, "["); i >= 0 {
+			state = header[i:]
+		}
+		counts[state+"\n"+goroutineArgs.ReplaceAllString(stack, "(...)")]++
+	}
+
+	groups := make([]string, 0, len(counts))
+	for group := range counts {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if counts[groups[i]] != counts[groups[j]] {
+			return counts[groups[i]] > counts[groups[j]]
+		}
+		return groups[i] < groups[j]
+	})
+
+	var b strings.Builder
+	for i, group := range groups {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "%d goroutines %s", counts[group], group)
+	}
+	return b.String()
+}
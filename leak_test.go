@@ -0,0 +1,76 @@
+package bugsnack
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeakDetector(t *testing.T) {
+	timers := stubAfterFunc(t)
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	count := 100
+	rr := &recordingReporter{}
+	ld := &LeakDetector{
+		Reporter:  rr,
+		Threshold: 50,
+		Interval:  time.Minute,
+		Window:    5 * time.Minute,
+		Count:     func() int { return count },
+	}
+	ld.Start()
+
+	tick := func(goroutines int) {
+		clock = clock.Add(time.Minute)
+		count = goroutines
+		ts := *timers
+		ts[len(ts)-1].f()
+	}
+
+	// slow growth never adds up to the threshold within the window
+	for _, goroutines := range []int{110, 120, 130, 140, 150, 160} {
+		tick(goroutines)
+	}
+	if len(rr.errs) != 0 {
+		t.Fatalf("expected no report below the threshold, got %v", rr.errs)
+	}
+
+	tick(180)
+	if len(rr.errs) != 1 {
+		t.Fatalf("expected a report past the threshold, got %d", len(rr.errs))
+	}
+	if msg := rr.errs[0].Error(); !strings.Contains(msg, "180 goroutines, up from 120 in 5m0s") {
+		t.Errorf("expected the growth in the message, got %q", msg)
+	}
+	tab := (*rr.metadata[0].EventMetadata)["goroutines"].(map[string]interface{})
+	if dump := tab["dump"].(string); !strings.Contains(dump, "TestLeakDetector") {
+		t.Errorf("expected a goroutine dump, got %q", dump)
+	}
+
+	tick(190)
+	if len(rr.errs) != 1 {
+		t.Errorf("expected the same growth not to be reported twice, got %d reports", len(rr.errs))
+	}
+
+	ld.Stop()
+	if !(*timers)[len(*timers)-1].stopped {
+		t.Errorf("expected Stop to stop sampling")
+	}
+}
+
+func TestGroupGoroutines(t *testing.T) {
+	dump := strings.Join([]string{
+		"goroutine 1 [running]:\nmain.main()\n\t/app/main.go:10 +0x1d",
+		"goroutine 7 [chan receive]:\nmain.worker(0xc000010000, 0x2)\n\t/app/main.go:20 +0x25",
+		"goroutine 8 [chan receive]:\nmain.worker(0xc000010040, 0x3)\n\t/app/main.go:20 +0x25",
+	}, "\n\n")
+
+	want := "2 goroutines [chan receive]:\nmain.worker(...)\n\t/app/main.go:20 +0x25\n\n" +
+		"1 goroutines [running]:\nmain.main()\n\t/app/main.go:10 +0x1d"
+	if got := groupGoroutines(dump); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,68 @@
+package bugsnack
+
+import (
+	"bytes"
+	"sync"
+)
+
+// A LogBuffer keeps the most recent lines written to it, bounded
+// both by number of lines and by total size. Wire your logger into
+// it (e.g. with io.MultiWriter) and set it as a BugsnagReporter's
+// Logs to attach the log tail to every event.
+type LogBuffer struct {
+	maxLines int
+	maxBytes int
+
+	mu      sync.Mutex
+	lines   []string
+	size    int
+	partial []byte
+}
+
+// NewLogBuffer returns a LogBuffer holding at most maxLines
+// lines and maxBytes bytes
+func NewLogBuffer(maxLines, maxBytes int) *LogBuffer {
+	return &LogBuffer{maxLines: maxLines, maxBytes: maxBytes}
+}
+
+// Write adds p to the buffer, evicting the oldest lines as needed.
+// Incomplete lines are held back until their newline is written.
+func (lb *LogBuffer) Write(p []byte) (int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.partial = append(lb.partial, p...)
+	for {
+		i := bytes.IndexByte(lb.partial, '\n')
+		if i < 0 {
+			break
+		}
+		lb.push(string(lb.partial[:i]))
+		lb.partial = lb.partial[i+1:]
+	}
+	if len(lb.partial) > lb.maxBytes {
+		lb.partial = lb.partial[:lb.maxBytes]
+	}
+
+	return len(p), nil
+}
+
+func (lb *LogBuffer) push(line string) {
+	if len(line) > lb.maxBytes {
+		line = line[:lb.maxBytes]
+	}
+	lb.lines = append(lb.lines, line)
+	lb.size += len(line)
+
+	for len(lb.lines) > lb.maxLines || lb.size > lb.maxBytes {
+		lb.size -= len(lb.lines[0])
+		lb.lines = lb.lines[1:]
+	}
+}
+
+// Lines returns the buffered lines, oldest first
+func (lb *LogBuffer) Lines() []string {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return append([]string(nil), lb.lines...)
+}
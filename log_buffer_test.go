@@ -0,0 +1,46 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLogBufferEviction(t *testing.T) {
+	lb := NewLogBuffer(3, 1024)
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(lb, "line %d\n", i)
+	}
+	fmt.Fprint(lb, "incomplete")
+
+	if got, want := lb.Lines(), []string{"line 3", "line 4", "line 5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	fmt.Fprint(lb, " line\n")
+	if got := lb.Lines(); got[2] != "incomplete line" {
+		t.Errorf("expected the completed line last, got %q", got)
+	}
+
+	lb = NewLogBuffer(100, 10)
+	fmt.Fprint(lb, "aaaa\nbbbb\ncccc\n")
+	if got, want := lb.Lines(), []string{"bbbb", "cccc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the byte limit to evict, got %q", got)
+	}
+}
+
+func TestLogBufferAttachment(t *testing.T) {
+	lb := NewLogBuffer(10, 1024)
+	fmt.Fprint(lb, "connecting to db\nretrying\n")
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Logs: lb}
+	er.Report(context.Background(), errors.New("log tail test"))
+
+	tab := sentEvent(t, doer, 0)["metaData"].(map[string]interface{})["logs"].(map[string]interface{})
+	if got := tab["lines"].([]interface{}); len(got) != 2 || got[0] != "connecting to db" || got[1] != "retrying" {
+		t.Errorf("unexpected logs tab %v", tab)
+	}
+}
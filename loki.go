@@ -0,0 +1,287 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLokiBatchSize = 100
+
+	// maxLokiBacklog bounds, in batches, how many entries are kept for
+	// the next push while Loki can't be reached
+	maxLokiBacklog = 10
+)
+
+// A LokiReporter sends errors to Grafana Loki's push API as log lines,
+// in streams labelled with the app, severity and release stage. Errors
+// are batched: a batch is pushed once it holds BatchSize errors, once
+// FlushInterval is over, and by Flush. If a push fails, its entries are
+// kept to be pushed with the next batch, or after another FlushInterval
+// (dropping the oldest once there are more than ten batches' worth).
+type LokiReporter struct {
+	// Doer sends the requests, defaulting to http.DefaultClient
+	Doer Doer
+
+	// URL is the push endpoint, e.g. http://loki:3100/loki/api/v1/push
+	URL string
+
+	// Header is added to every request, e.g. X-Scope-OrgID
+	Header http.Header
+
+	App          string
+	ReleaseStage string
+
+	// Labels are added to every stream
+	Labels map[string]string
+
+	FlushInterval time.Duration
+
+	// BatchSize defaults to 100
+	BatchSize int
+
+	mu         sync.Mutex
+	batchStart time.Time
+	batch      []lokiEntry
+	last       map[string]time.Time
+	timer      interface{ Stop() bool }
+}
+
+type lokiEntry struct {
+	labels map[string]string
+	time   time.Time
+	line   string
+}
+
+// Report adds the error to the current batch, pushing it if it is due.
+// Private errors are skipped.
+func (lr *LokiReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = lr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE is like Report, but returns the error of pushing the batch,
+// if that was due
+func (lr *LokiReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	if err == nil || isPrivate(err, metadata) {
+		return nil
+	}
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+
+	stage := ContextReleaseStage(ctx)
+	if stage == "" {
+		stage = lr.ReleaseStage
+	}
+	labels := map[string]string{}
+	for k, v := range lr.Labels {
+		labels[k] = v
+	}
+	labels["app"] = lr.App
+	labels["severity"] = severity(md)
+	labels["release_stage"] = stage
+
+	record := map[string]interface{}{
+		"message":    err.Error(),
+		"errorClass": errorClass(err, md),
+	}
+	if md.Context != "" {
+		record["context"] = md.Context
+	}
+	if !IsZeroInterface(md.EventMetadata) {
+		record["metaData"] = resolveThunks(*md.EventMetadata)
+	}
+	line, encodeErr := json.Marshal(record)
+	if encodeErr != nil {
+		delete(record, "metaData")
+		line, _ = json.Marshal(record)
+	}
+
+	t := now()
+	lr.mu.Lock()
+	if len(lr.batch) == 0 {
+		lr.batchStart = t
+		lr.arm()
+	}
+	lr.batch = append(lr.batch, lokiEntry{labels: labels, time: t, line: string(line)})
+	var batch []lokiEntry
+	if len(lr.batch) >= lr.batchSize() || t.Sub(lr.batchStart) >= lr.FlushInterval {
+		batch = lr.take()
+	}
+	lr.mu.Unlock()
+
+	if batch == nil {
+		return nil
+	}
+	return lr.send(ctx, batch)
+}
+
+// Flush pushes the current batch
+func (lr *LokiReporter) Flush(ctx context.Context) error {
+	lr.mu.Lock()
+	batch := lr.take()
+	lr.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return lr.send(ctx, batch)
+}
+
+func (lr *LokiReporter) batchSize() int {
+	if lr.BatchSize <= 0 {
+		return defaultLokiBatchSize
+	}
+	return lr.BatchSize
+}
+
+// send pushes batch, putting it back in front of the current batch if
+// that failed
+func (lr *LokiReporter) send(ctx context.Context, batch []lokiEntry) error {
+	err := lr.push(ctx, batch)
+	if err == nil {
+		return nil
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	// the batch was due, so it goes out with the next report
+	lr.batch = append(batch, lr.batch...)
+	lr.batchStart = time.Time{}
+	if limit := maxLokiBacklog * lr.batchSize(); len(lr.batch) > limit {
+		lr.batch = lr.batch[len(lr.batch)-limit:]
+	}
+	if lr.timer != nil {
+		lr.timer.Stop()
+		lr.timer = nil
+	}
+	lr.arm()
+	return err
+}
+
+// arm starts a timer pushing the current batch once FlushInterval is
+// over, so that it doesn't wait for another report
+func (lr *LokiReporter) arm() {
+	if lr.FlushInterval <= 0 || lr.timer != nil {
+		return
+	}
+	start := lr.batchStart
+	lr.timer = afterFunc(lr.FlushInterval, func() { lr.expire(start) })
+}
+
+// expire pushes the batch started at start, unless it was already
+// taken
+func (lr *LokiReporter) expire(start time.Time) {
+	lr.mu.Lock()
+	var batch []lokiEntry
+	if len(lr.batch) > 0 && lr.batchStart.Equal(start) {
+		batch = lr.take()
+	}
+	lr.mu.Unlock()
+
+	if batch != nil {
+		_ = lr.send(context.Background(), batch)
+	}
+}
+
+// take returns the current batch and starts afresh. Loki rejects
+// entries older than the last one pushed to their stream, so
+// timestamps are nudged forward where needed.
+func (lr *LokiReporter) take() []lokiEntry {
+	batch := lr.batch
+	lr.batch = nil
+	if lr.timer != nil {
+		lr.timer.Stop()
+		lr.timer = nil
+	}
+	if lr.last == nil {
+		lr.last = map[string]time.Time{}
+	}
+	for i := range batch {
+		key := lokiStreamKey(batch[i].labels)
+		if last, ok := lr.last[key]; ok && !batch[i].time.After(last) {
+			batch[i].time = last.Add(time.Nanosecond)
+		}
+		lr.last[key] = batch[i].time
+	}
+	return batch
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (lr *LokiReporter) push(ctx context.Context, batch []lokiEntry) error {
+	streams := map[string]*lokiStream{}
+	var keys []string
+	for _, entry := range batch {
+		key := lokiStreamKey(entry.labels)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: entry.labels}
+			streams[key] = stream
+			keys = append(keys, key)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.time.UnixNano(), 10), entry.line})
+	}
+	payload := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range keys {
+		payload.Streams = append(payload.Streams, streams[key])
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lr.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range lr.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	doer := lr.Doer
+	if doer == nil {
+		doer = defaultDoer
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, defaultResponseReadLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("could not push to loki: %s: %s", resp.Status, respBody)
+	}
+	return nil
+}
+
+// lokiStreamKey identifies the stream with the given labels
+func lokiStreamKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%q,", name, labels[name])
+	}
+	return b.String()
+}
@@ -0,0 +1,224 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type lokiPush struct {
+	Streams []struct {
+		Stream map[string]string
+		Values [][2]string
+	}
+}
+
+func TestLokiReporter(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	doer := &MockDoer{}
+	lr := &LokiReporter{
+		Doer:          doer,
+		URL:           "http://loki:3100/loki/api/v1/push",
+		App:           "billing",
+		ReleaseStage:  "production",
+		Labels:        map[string]string{"region": "eu"},
+		FlushInterval: time.Minute,
+	}
+	ctx := context.Background()
+
+	lr.Report(ctx, errors.New("card declined"), &BugsnagMetadata{Severity: "warning", Context: "charge"})
+	lr.Report(ctx, errors.New("db down"))
+	// same instant, same stream
+	lr.Report(ctx, errors.New("db still down"))
+	if len(doer.Requests()) != 0 {
+		t.Fatalf("expected errors to be batched, got %d pushes", len(doer.Requests()))
+	}
+
+	clock = clock.Add(time.Minute)
+	lr.Report(ctx, errors.New("db down again"))
+	if len(doer.Requests()) != 1 {
+		t.Fatalf("expected a push once the flush interval passed, got %d", len(doer.Requests()))
+	}
+
+	var push lokiPush
+	if err := json.Unmarshal(doer.Bodies()[0], &push); err != nil {
+		t.Fatal(err)
+	}
+	if len(push.Streams) != 2 {
+		t.Fatalf("expected a stream per severity, got %+v", push)
+	}
+	warnings, errs := push.Streams[0], push.Streams[1]
+	want := map[string]string{"app": "billing", "severity": "warning", "release_stage": "production", "region": "eu"}
+	if len(warnings.Stream) != len(want) {
+		t.Errorf("expected labels %v, got %v", want, warnings.Stream)
+	}
+	for k, v := range want {
+		if warnings.Stream[k] != v {
+			t.Errorf("expected label %s=%s, got %v", k, v, warnings.Stream)
+		}
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(warnings.Values[0][1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["message"] != "card declined" || line["context"] != "charge" || line["errorClass"] != "*errors.errorString" {
+		t.Errorf("unexpected log line %v", line)
+	}
+
+	if len(errs.Values) != 3 || errs.Stream["severity"] != "error" {
+		t.Fatalf("expected 3 errors in the error stream, got %+v", errs)
+	}
+	start := clock.Add(-time.Minute).UnixNano()
+	for i, want := range []int64{start, start + 1, clock.UnixNano()} {
+		if got := errs.Values[i][0]; got != strconv.FormatInt(want, 10) {
+			t.Errorf("entry %d: expected timestamp %d, got %s", i, want, got)
+		}
+	}
+
+	// the clock going backwards must not make Loki reject the entry
+	clock = clock.Add(-time.Second)
+	lr.Report(ctx, errors.New("skewed"))
+	if err := lr.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	push = lokiPush{}
+	if err := json.Unmarshal(doer.Bodies()[1], &push); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := push.Streams[0].Values[0][0], strconv.FormatInt(clock.Add(time.Second).UnixNano()+1, 10); got != want {
+		t.Errorf("expected timestamp %s, got %s", want, got)
+	}
+}
+
+func TestLokiReporterResolvesThunks(t *testing.T) {
+	doer := &MockDoer{}
+	lr := &LokiReporter{Doer: doer, URL: "http://loki:3100/loki/api/v1/push", App: "billing"}
+	ctx := context.Background()
+
+	lr.Report(ctx, errors.New("slow query"), &BugsnagMetadata{EventMetadata: &map[string]interface{}{
+		"query": map[string]interface{}{"plan": func() interface{} { return "seq scan" }},
+	}})
+	if err := lr.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var push lokiPush
+	if err := json.Unmarshal(doer.Bodies()[0], &push); err != nil {
+		t.Fatal(err)
+	}
+	var line struct {
+		MetaData map[string]map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(push.Streams[0].Values[0][1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if plan := line.MetaData["query"]["plan"]; plan != "seq scan" {
+		t.Errorf("expected the thunk's result to be logged, got %v", line.MetaData)
+	}
+}
+
+func TestLokiReporterKeepsFailedBatches(t *testing.T) {
+	doer := (&MockDoer{}).
+		FailWith(errors.New("connection reset")).
+		RespondWith(http.StatusBadGateway, "").
+		RespondWith(http.StatusNoContent, "")
+	lr := &LokiReporter{Doer: doer, URL: "http://loki:3100/loki/api/v1/push", App: "billing", FlushInterval: time.Hour, BatchSize: 1}
+	ctx := context.Background()
+
+	if err := lr.ReportE(ctx, errors.New("first")); err == nil || err.Error() != "connection reset" {
+		t.Errorf("expected the transport error, got %v", err)
+	}
+	if err := lr.ReportE(ctx, errors.New("second")); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("expected the failed push, got %v", err)
+	}
+	if err := lr.ReportE(ctx, errors.New("third")); err != nil {
+		t.Fatal(err)
+	}
+
+	var push lokiPush
+	if err := json.Unmarshal(doer.Bodies()[2], &push); err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	var last int64
+	for _, value := range push.Streams[0].Values {
+		var line map[string]interface{}
+		if err := json.Unmarshal([]byte(value[1]), &line); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, line["message"].(string))
+		ts, _ := strconv.ParseInt(value[0], 10, 64)
+		if ts <= last {
+			t.Errorf("expected increasing timestamps, got %v", push.Streams[0].Values)
+		}
+		last = ts
+	}
+	if got := strings.Join(messages, ","); got != "first,second,third" {
+		t.Errorf("expected the failed entries to be pushed again, got %s", got)
+	}
+	if err := lr.Flush(ctx); err != nil || len(doer.Requests()) != 3 {
+		t.Errorf("expected nothing left to flush, got %v after %d requests", err, len(doer.Requests()))
+	}
+}
+
+func TestLokiReporterBacklogLimit(t *testing.T) {
+	lr := &LokiReporter{Doer: (&MockDoer{}).FailWith(errors.New("connection refused")), FlushInterval: time.Hour, BatchSize: 1}
+
+	for i := 0; i < maxLokiBacklog+5; i++ {
+		lr.Report(context.Background(), errors.New("unpushed"))
+	}
+	if n := len(lr.batch); n != maxLokiBacklog {
+		t.Errorf("expected the backlog to be capped at %d entries, got %d", maxLokiBacklog, n)
+	}
+}
+
+func TestLokiReporterFlushInterval(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+	timers := stubAfterFunc(t)
+
+	doer := (&MockDoer{}).FailWith(errors.New("connection reset")).RespondWith(http.StatusNoContent, "")
+	lr := &LokiReporter{Doer: doer, URL: "http://loki:3100/loki/api/v1/push", FlushInterval: time.Minute}
+	ctx := context.Background()
+
+	lr.Report(ctx, errors.New("rare"))
+	clock = clock.Add(time.Second)
+	lr.Report(ctx, errors.New("rarer"))
+	if len(*timers) != 1 || (*timers)[0].d != time.Minute {
+		t.Fatalf("expected a single timer for the interval, got %v", *timers)
+	}
+
+	// nothing else is reported, the timer pushes the batch anyway
+	clock = clock.Add(time.Minute)
+	(*timers)[0].f()
+	if len(doer.Requests()) != 1 {
+		t.Fatalf("expected a push on time, got %d", len(doer.Requests()))
+	}
+
+	// which failed, so another timer tries again
+	if len(*timers) != 2 {
+		t.Fatalf("expected a timer to retry the failed push, got %v", *timers)
+	}
+	(*timers)[1].f()
+	var push lokiPush
+	if err := json.Unmarshal(doer.Bodies()[1], &push); err != nil {
+		t.Fatal(err)
+	}
+	if len(doer.Requests()) != 2 || len(push.Streams[0].Values) != 2 {
+		t.Errorf("expected the batch to be pushed again, got %d requests, %+v", len(doer.Requests()), push)
+	}
+
+	if err := lr.Flush(ctx); err != nil || len(doer.Requests()) != 2 {
+		t.Errorf("expected nothing left to flush, got %v after %d requests", err, len(doer.Requests()))
+	}
+}
@@ -0,0 +1,29 @@
+package bugsnack
+
+import "context"
+
+// ReportMessage reports msg as a handled event with the given severity
+// (e.g. "warning" or "info"), for conditions that are expected but
+// worth keeping an eye on:
+//
+//	bugsnack.ReportMessage(ctx, er, "cache miss rate above 50%", "warning")
+//
+// The stack trace starts at the caller and the error class defaults
+// to msg itself.
+func ReportMessage(ctx context.Context, er ErrorReporter, msg, severity string, metadata ...interface{}) {
+	err := &messageError{msg: msg, pcs: callers(1)}
+
+	md := mergeMetadata(&BugsnagMetadata{ErrorClass: msg}, metadataFrom(metadata))
+	md = mergeMetadata(md, &BugsnagMetadata{Severity: severity})
+	er.Report(ctx, err, withMetadata(metadata, md)...)
+}
+
+// messageError is the synthetic error reported by ReportMessage
+type messageError struct {
+	msg string
+	pcs []uintptr
+}
+
+func (me *messageError) Error() string { return me.msg }
+
+func (me *messageError) Callers() []uintptr { return me.pcs }
@@ -0,0 +1,28 @@
+package bugsnack
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReportMessage(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	ReportMessage(context.Background(), er, "cache miss rate above 50%", "warning", &BugsnagMetadata{Context: "cache"})
+
+	event := sentEvent(t, doer, 0)
+	if event["severity"] != "warning" || event["unhandled"] != false || event["context"] != "cache" {
+		t.Errorf("unexpected event %v", event)
+	}
+	exception := event["exceptions"].([]interface{})[0].(map[string]interface{})
+	if exception["message"] != "cache miss rate above 50%" || exception["errorClass"] != "cache miss rate above 50%" {
+		t.Errorf("unexpected exception %v", exception)
+	}
+	frames := exception["stacktrace"].([]interface{})
+	if len(frames) == 0 {
+		t.Fatal("expected a stack trace")
+	}
+	if top := frames[0].(map[string]interface{}); top["method"] != "TestReportMessage" || top["file"] != "message_test.go" {
+		t.Errorf("expected the stack to start at the caller, got %v", top)
+	}
+}
@@ -0,0 +1,307 @@
+package bugsnack
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// metadataFrom returns the *BugsnagMetadata passed to Report,
+// if there is one
+func metadataFrom(meta []interface{}) *BugsnagMetadata {
+	if len(meta) == 0 {
+		return nil
+	}
+	metadata, _ := meta[0].(*BugsnagMetadata)
+	return metadata
+}
+
+// groupingKey returns the key used to decide whether two reports
+// are the same error, preferring an explicit GroupingHash
+func groupingKey(err error, metadata *BugsnagMetadata) string {
+	if metadata != nil && metadata.GroupingHash != "" {
+		return metadata.GroupingHash
+	}
+	return err.Error()
+}
+
+// prunedMarker replaces metadata that was too deep or too large to send
+const prunedMarker = "[pruned]"
+
+// pruneMetadata returns a copy of v with containers nested deeper than
+// maxDepth replaced by prunedMarker, and containers holding more than
+// maxItems entries cut down to size. Zero disables either limit.
+func pruneMetadata(v interface{}, depth, maxDepth, maxItems int) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return v
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+	default:
+		return v
+	}
+
+	if maxDepth > 0 && depth > maxDepth {
+		return prunedMarker
+	}
+
+	if rv.Kind() == reflect.Map {
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]reflect.Value, rv.Len())
+		for _, k := range rv.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			keys = append(keys, key)
+			values[key] = rv.MapIndex(k)
+		}
+		sort.Strings(keys)
+
+		pruned := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			if maxItems > 0 && i == maxItems {
+				pruned[prunedMarker] = fmt.Sprintf("%d more items", len(keys)-maxItems)
+				break
+			}
+			pruned[key] = pruneMetadata(values[key].Interface(), depth+1, maxDepth, maxItems)
+		}
+		return pruned
+	}
+
+	pruned := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if maxItems > 0 && i == maxItems {
+			pruned = append(pruned, fmt.Sprintf("%s %d more items", prunedMarker, rv.Len()-maxItems))
+			break
+		}
+		pruned = append(pruned, pruneMetadata(rv.Index(i).Interface(), depth+1, maxDepth, maxItems))
+	}
+	return pruned
+}
+
+// resolveThunks returns v with any func() interface{} values within
+// its maps and slices replaced by their results, so that expensive
+// metadata is only computed for events which are actually sent
+func resolveThunks(v interface{}) interface{} {
+	switch v := v.(type) {
+	case func() interface{}:
+		return resolveThunks(v())
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			resolved[k] = resolveThunks(value)
+		}
+		return resolved
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, value := range v {
+			resolved[i] = resolveThunks(value)
+		}
+		return resolved
+	}
+	return v
+}
+
+// severity returns the severity of an event with the given metadata,
+// which is "error" unless set otherwise
+func severity(metadata *BugsnagMetadata) string {
+	if metadata == nil || metadata.Severity == "" {
+		return "error"
+	}
+	return metadata.Severity
+}
+
+// errorClass returns the ErrorClass of an error, defaulting to its type
+func errorClass(err error, metadata *BugsnagMetadata) string {
+	if metadata != nil && metadata.ErrorClass != "" {
+		return metadata.ErrorClass
+	}
+	if err == nil {
+		return ""
+	}
+	return reflect.TypeOf(withoutWrappers(err)).String()
+}
+
+// mergeMetadata returns a new BugsnagMetadata with the values of
+// override layered on top of base. EventMetadata is merged per tab,
+// so both can contribute keys to the same tab.
+func mergeMetadata(base, override *BugsnagMetadata) *BugsnagMetadata {
+	if base == nil {
+		base = &BugsnagMetadata{}
+	}
+	if override == nil {
+		override = &BugsnagMetadata{}
+	}
+
+	merged := *base
+	if override.ErrorClass != "" {
+		merged.ErrorClass = override.ErrorClass
+	}
+	if override.Context != "" {
+		merged.Context = override.Context
+	}
+	if override.GroupingHash != "" {
+		merged.GroupingHash = override.GroupingHash
+	}
+	if override.Severity != "" {
+		merged.Severity = override.Severity
+	}
+	if override.User != nil {
+		merged.User = override.User
+	}
+	if override.SeverityReason != nil {
+		merged.SeverityReason = override.SeverityReason
+	}
+	if override.ErrorCode != "" {
+		merged.ErrorCode = override.ErrorCode
+	}
+	merged.Unhandled = base.Unhandled || override.Unhandled
+	merged.Private = base.Private || override.Private
+	merged.Tags = mergeTags(base.Tags, override.Tags)
+
+	if !IsZeroInterface(base.EventMetadata) || !IsZeroInterface(override.EventMetadata) {
+		eventMetadata := map[string]interface{}{}
+		for _, md := range []*map[string]interface{}{base.EventMetadata, override.EventMetadata} {
+			if IsZeroInterface(md) {
+				continue
+			}
+			for name, tab := range *md {
+				existing, ok := eventMetadata[name].(map[string]interface{})
+				values, isMap := tab.(map[string]interface{})
+				if !ok || !isMap {
+					eventMetadata[name] = tab
+					continue
+				}
+				combined := make(map[string]interface{}, len(existing)+len(values))
+				for k, v := range existing {
+					combined[k] = v
+				}
+				for k, v := range values {
+					combined[k] = v
+				}
+				eventMetadata[name] = combined
+			}
+		}
+		merged.EventMetadata = &eventMetadata
+	}
+
+	return &merged
+}
+
+// mergeTags returns the union of base and override, with override
+// winning, without modifying either
+func mergeTags(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+	if len(base) == 0 {
+		return override
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// withMetadata returns meta with its *BugsnagMetadata replaced by
+// metadata, adding it if there was none
+func withMetadata(meta []interface{}, metadata *BugsnagMetadata) []interface{} {
+	if len(meta) > 0 {
+		if _, ok := meta[0].(*BugsnagMetadata); ok {
+			meta = append([]interface{}(nil), meta...)
+			meta[0] = metadata
+			return meta
+		}
+	}
+	return append([]interface{}{metadata}, meta...)
+}
+
+// WithReportMetadata wraps err so that meta travels with it, letting
+// the code where an error happens annotate it once instead of passing
+// metadata up to where it is reported. BugsnagReporter merges it with
+// the metadata given to Report, which wins; where several wrappers are
+// nested, the outermost wins.
+func WithReportMetadata(err error, meta *BugsnagMetadata) error {
+	if err == nil {
+		return nil
+	}
+	return &metadataError{error: err, metadata: meta}
+}
+
+// metadataError is an error carrying metadata for its report
+type metadataError struct {
+	error
+	metadata *BugsnagMetadata
+}
+
+func (me *metadataError) Cause() error { return me.error }
+
+func (me *metadataError) Unwrap() error { return me.error }
+
+// errorCodeTag is the tag carrying a BugsnagMetadata's ErrorCode
+const errorCodeTag = "error_code"
+
+// WithErrorCode attaches an internal error code (see
+// BugsnagMetadata.ErrorCode) to err
+func WithErrorCode(err error, code string) error {
+	return WithReportMetadata(err, &BugsnagMetadata{ErrorCode: code})
+}
+
+// WithPrivate marks err as private, so that it is not sent
+// to external services
+func WithPrivate(err error) error {
+	return WithReportMetadata(err, &BugsnagMetadata{Private: true})
+}
+
+// isPrivate reports whether an error must not be sent to external
+// services, going by both the metadata passed to Report and any
+// attached to the error
+func isPrivate(err error, meta []interface{}) bool {
+	if md := metadataFrom(meta); md != nil && md.Private {
+		return true
+	}
+	md := errorMetadata(err)
+	return md != nil && md.Private
+}
+
+// errorMetadata returns the metadata attached to err's chain with
+// WithReportMetadata, or nil if there is none
+func errorMetadata(err error) *BugsnagMetadata {
+	var metadata *BugsnagMetadata
+	for e := err; e != nil; e = unwrap(e) {
+		if me, ok := e.(*metadataError); ok {
+			if metadata == nil {
+				metadata = me.metadata
+			} else {
+				metadata = mergeMetadata(me.metadata, metadata)
+			}
+		}
+	}
+	return metadata
+}
+
+// withoutWrappers strips any wrappers added by bugsnack itself (e.g.
+// WithReportMetadata) from the outside of err
+func withoutWrappers(err error) error {
+	for {
+		switch e := err.(type) {
+		case *metadataError:
+			err = e.error
+		case *timestampError:
+			err = e.error
+		default:
+			return err
+		}
+	}
+}
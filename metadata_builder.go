@@ -0,0 +1,106 @@
+package bugsnack
+
+// A MetadataBuilder builds a *BugsnagMetadata a piece at a time:
+//
+//	er.Report(ctx, err, bugsnack.NewMetadata().
+//		Severity("warning").
+//		User("42", "Jane", "jane@example.com").
+//		Tab("request", map[string]interface{}{"path": r.URL.Path}).
+//		Build())
+type MetadataBuilder struct {
+	metadata BugsnagMetadata
+	tabs     map[string]map[string]interface{}
+}
+
+// NewMetadata returns an empty MetadataBuilder
+func NewMetadata() *MetadataBuilder {
+	return &MetadataBuilder{}
+}
+
+// Class sets the ErrorClass
+func (b *MetadataBuilder) Class(class string) *MetadataBuilder {
+	b.metadata.ErrorClass = class
+	return b
+}
+
+// Context sets the Context
+func (b *MetadataBuilder) Context(context string) *MetadataBuilder {
+	b.metadata.Context = context
+	return b
+}
+
+// Grouping sets the GroupingHash
+func (b *MetadataBuilder) Grouping(hash string) *MetadataBuilder {
+	b.metadata.GroupingHash = hash
+	return b
+}
+
+// Severity sets the Severity
+func (b *MetadataBuilder) Severity(severity string) *MetadataBuilder {
+	b.metadata.Severity = severity
+	return b
+}
+
+// Code sets the ErrorCode
+func (b *MetadataBuilder) Code(code string) *MetadataBuilder {
+	b.metadata.ErrorCode = code
+	return b
+}
+
+// User sets the user affected by the error
+func (b *MetadataBuilder) User(id, name, email string) *MetadataBuilder {
+	b.metadata.User = &BugsnagUser{ID: id, Name: name, Email: email}
+	return b
+}
+
+// Tag adds a tag
+func (b *MetadataBuilder) Tag(key, value string) *MetadataBuilder {
+	b.metadata.Tags = mergeTags(b.metadata.Tags, map[string]string{key: value})
+	return b
+}
+
+// Tab adds values to the EventMetadata tab called name
+func (b *MetadataBuilder) Tab(name string, values map[string]interface{}) *MetadataBuilder {
+	if b.tabs == nil {
+		b.tabs = map[string]map[string]interface{}{}
+	}
+	tab := b.tabs[name]
+	if tab == nil {
+		tab = make(map[string]interface{}, len(values))
+		b.tabs[name] = tab
+	}
+	for k, v := range values {
+		tab[k] = v
+	}
+	return b
+}
+
+// Unhandled marks the error as unhandled
+func (b *MetadataBuilder) Unhandled() *MetadataBuilder {
+	b.metadata.Unhandled = true
+	return b
+}
+
+// Private marks the error as private
+func (b *MetadataBuilder) Private() *MetadataBuilder {
+	b.metadata.Private = true
+	return b
+}
+
+// Build returns the metadata built so far. Later changes to the
+// builder do not affect it.
+func (b *MetadataBuilder) Build() *BugsnagMetadata {
+	metadata := mergeMetadata(&b.metadata, nil)
+	if len(b.tabs) > 0 {
+		tabs := make(map[string]interface{}, len(b.tabs))
+		for name, tab := range b.tabs {
+			values := make(map[string]interface{}, len(tab))
+			for k, v := range tab {
+				values[k] = v
+			}
+			tabs[name] = values
+		}
+		metadata.EventMetadata = &tabs
+	}
+	return metadata
+}
@@ -0,0 +1,63 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMetadataBuilder(t *testing.T) {
+	b := NewMetadata().
+		Class("db.timeout").
+		Context("fetchWorker").
+		Grouping("timeouts").
+		Severity("warning").
+		User("42", "Jane", "jane@example.com").
+		Tag("region", "eu").
+		Tab("request", map[string]interface{}{"path": "/users"}).
+		Tab("request", map[string]interface{}{"method": "GET"}).
+		Unhandled().
+		Private()
+	md := b.Build()
+
+	want := &BugsnagMetadata{
+		ErrorClass:   "db.timeout",
+		Context:      "fetchWorker",
+		GroupingHash: "timeouts",
+		Severity:     "warning",
+		User:         &BugsnagUser{ID: "42", Name: "Jane", Email: "jane@example.com"},
+		Tags:         map[string]string{"region": "eu"},
+		EventMetadata: &map[string]interface{}{
+			"request": map[string]interface{}{"path": "/users", "method": "GET"},
+		},
+		Unhandled: true,
+		Private:   true,
+	}
+	if !reflect.DeepEqual(md, want) {
+		t.Errorf("expected %+v, got %+v", want, md)
+	}
+
+	b.Tab("request", map[string]interface{}{"path": "/changed"}).Severity("error")
+	if md.Severity != "warning" || (*md.EventMetadata)["request"].(map[string]interface{})["path"] != "/users" {
+		t.Errorf("expected built metadata not to change with the builder, got %+v", md)
+	}
+}
+
+func TestMetadataBuilderEmpty(t *testing.T) {
+	md := NewMetadata().Severity("info").Build()
+	if !reflect.DeepEqual(md, &BugsnagMetadata{Severity: "info"}) {
+		t.Errorf("expected only the severity to be set, got %+v", md)
+	}
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), errors.New("builder"), NewMetadata().User("42", "", "").Build())
+	event := sentEvent(t, doer, 0)
+	if user := event["user"].(map[string]interface{}); len(user) != 1 || user["id"] != "42" {
+		t.Errorf("expected only the user id to be sent, got %v", user)
+	}
+	if _, ok := event["groupingHash"]; ok {
+		t.Errorf("expected no grouping hash, got %v", event["groupingHash"])
+	}
+}
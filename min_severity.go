@@ -0,0 +1,40 @@
+package bugsnack
+
+import "context"
+
+// A MinSeverityReporter only passes on errors at or above MinSeverity
+// ("info", "warning" or "error"), so that each branch of a
+// MultiReporter can have its own threshold, e.g. a log file getting
+// everything while a pager only gets errors. Unknown severities count
+// as errors.
+type MinSeverityReporter struct {
+	Reporter    ErrorReporter
+	MinSeverity string
+}
+
+// Report sends the error on to the underlying Reporter if it is
+// severe enough
+func (mr *MinSeverityReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+	if severityRank(severity(md)) < severityRank(mr.MinSeverity) {
+		return
+	}
+	mr.Reporter.Report(ctx, err, metadata...)
+}
+
+// Flush flushes the underlying Reporter
+func (mr *MinSeverityReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, mr.Reporter)
+}
+
+// severityRank orders severities from least to most severe
+func severityRank(severity string) int {
+	switch severity {
+	case "", "info":
+		return 0
+	case "warning":
+		return 1
+	default:
+		return 2
+	}
+}
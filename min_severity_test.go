@@ -0,0 +1,28 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMinSeverityReporter(t *testing.T) {
+	file, pager := &recordingReporter{}, &recordingReporter{}
+	er := &MultiReporter{Reporters: []ErrorReporter{
+		&MinSeverityReporter{Reporter: file, MinSeverity: "info"},
+		&MinSeverityReporter{Reporter: pager, MinSeverity: "error"},
+	}}
+	ctx := context.Background()
+
+	er.Report(ctx, errors.New("slow query"), &BugsnagMetadata{Severity: "warning"})
+	er.Report(ctx, errors.New("cache miss"), &BugsnagMetadata{Severity: "info"})
+	er.Report(ctx, errors.New("database down"))
+	er.Report(ctx, WithReportMetadata(errors.New("disk full"), &BugsnagMetadata{Severity: "fatal"}))
+
+	if len(file.errs) != 4 {
+		t.Errorf("expected the file branch to get everything, got %v", file.errs)
+	}
+	if len(pager.errs) != 2 || pager.errs[0].Error() != "database down" || pager.errs[1].Error() != "disk full" {
+		t.Errorf("expected the pager branch to get errors only, got %v", pager.errs)
+	}
+}
@@ -0,0 +1,95 @@
+package bugsnack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// A MockDoer is a Doer for tests. It records every request and answers
+// with the programmed responses in order, repeating the last one once
+// they run out (or answering 200 OK if none were programmed).
+//
+//	doer := (&bugsnack.MockDoer{}).FailWith(err).RespondWith(http.StatusOK, "")
+type MockDoer struct {
+	mu        sync.Mutex
+	responses []mockResponse
+	requests  []*http.Request
+	bodies    [][]byte
+}
+
+type mockResponse struct {
+	status int
+	body   string
+	err    error
+}
+
+// RespondWith queues a response with the given status and body
+func (md *MockDoer) RespondWith(status int, body string) *MockDoer {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.responses = append(md.responses, mockResponse{status: status, body: body})
+	return md
+}
+
+// FailWith queues a transport error
+func (md *MockDoer) FailWith(err error) *MockDoer {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.responses = append(md.responses, mockResponse{err: err})
+	return md
+}
+
+// Do records req and returns the next programmed response
+func (md *MockDoer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.requests = append(md.requests, req)
+	md.bodies = append(md.bodies, body)
+
+	resp := mockResponse{status: http.StatusOK}
+	if len(md.responses) > 0 {
+		resp = md.responses[0]
+		if len(md.responses) > 1 {
+			md.responses = md.responses[1:]
+		}
+	}
+	if resp.err != nil {
+		return nil, resp.err
+	}
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", resp.status, http.StatusText(resp.status)),
+		StatusCode: resp.status,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(resp.body)),
+		Request:    req,
+	}, nil
+}
+
+// Requests returns the requests received so far. Their bodies
+// have already been read, use Bodies to inspect them.
+func (md *MockDoer) Requests() []*http.Request {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	return append([]*http.Request(nil), md.requests...)
+}
+
+// Bodies returns the bodies of the requests received so far
+func (md *MockDoer) Bodies() [][]byte {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	return append([][]byte(nil), md.bodies...)
+}
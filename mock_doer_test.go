@@ -0,0 +1,59 @@
+package bugsnack
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestMockDoer(t *testing.T) {
+	unreachable := errors.New("unreachable")
+	md := (&MockDoer{}).
+		FailWith(unreachable).
+		RespondWith(http.StatusInternalServerError, "oops").
+		RespondWith(http.StatusOK, "ok")
+
+	do := func(body string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodPost, "https://notify.bugsnag.com", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return md.Do(req)
+	}
+
+	if _, err := do("first"); err != unreachable {
+		t.Errorf("expected the programmed error, got %v", err)
+	}
+	for _, want := range []struct {
+		status int
+		body   string
+	}{
+		{http.StatusInternalServerError, "oops"},
+		{http.StatusOK, "ok"},
+		{http.StatusOK, "ok"},
+	} {
+		resp, err := do("next")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		if resp.StatusCode != want.status || string(body) != want.body {
+			t.Errorf("expected %d %q, got %d %q", want.status, want.body, resp.StatusCode, body)
+		}
+	}
+
+	if n := len(md.Requests()); n != 4 {
+		t.Errorf("expected 4 recorded requests, got %d", n)
+	}
+	if bodies := md.Bodies(); string(bodies[0]) != "first" || string(bodies[3]) != "next" {
+		t.Errorf("unexpected recorded bodies %q", bodies)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://notify.bugsnag.com", nil)
+	resp, err := (&MockDoer{}).Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 OK by default, got %v, %v", resp, err)
+	}
+}
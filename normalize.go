@@ -0,0 +1,31 @@
+package bugsnack
+
+import "regexp"
+
+// A Normalizer replaces the dynamic parts of messages matching
+// Pattern (such as IDs) with Placeholder
+type Normalizer struct {
+	Pattern     *regexp.Regexp
+	Placeholder string
+}
+
+// DefaultNormalizers replace quoted strings, UUIDs, email addresses,
+// hex and decimal numbers, in that order. Append to a copy of them
+// to recognize more.
+var DefaultNormalizers = []Normalizer{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<string>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`), "<email>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "<hex>"},
+	{regexp.MustCompile(`-?\b\d+(\.\d+)?`), "<n>"},
+}
+
+// NormalizeMessage turns a message into a template by applying each
+// normalizer in turn, so that "user 42 not found" and "user 99 not
+// found" both become "user <n> not found"
+func NormalizeMessage(msg string, normalizers []Normalizer) string {
+	for _, n := range normalizers {
+		msg = n.Pattern.ReplaceAllLiteralString(msg, n.Placeholder)
+	}
+	return msg
+}
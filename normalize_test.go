@@ -0,0 +1,47 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	for msg, want := range map[string]string{
+		"user 42 not found":                                 "user <n> not found",
+		`parsing "2017-05-05": invalid date`:                "parsing <string>: invalid date",
+		"order 5f1c2a9e-3b4d-4c6e-8f0a-1b2c3d4e5f60 failed": "order <uuid> failed",
+		"cannot email jane.doe+test@example.co.uk":          "cannot email <email>",
+		"bad pointer 0xc000123abc after 1.5s, retry -3":     "bad pointer <hex> after <n>s, retry <n>",
+		"user42 is unchanged":                               "user42 is unchanged",
+	} {
+		if got := NormalizeMessage(msg, DefaultNormalizers); got != want {
+			t.Errorf("NormalizeMessage(%q): expected %q, got %q", msg, want, got)
+		}
+	}
+
+	custom := append(append([]Normalizer(nil), DefaultNormalizers...),
+		Normalizer{regexp.MustCompile(`\bsku-\w+`), "<sku>"})
+	if got := NormalizeMessage("sku-AB12 out of stock", custom); got != "<sku> out of stock" {
+		t.Errorf("expected custom normalizers to apply, got %q", got)
+	}
+}
+
+func TestGroupByNormalizedMessage(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, GroupByNormalizedMessage: true}
+	ctx := context.Background()
+
+	er.Report(ctx, errors.New("user 42 not found"))
+	er.Report(ctx, errors.New("user 99 not found"))
+	er.Report(ctx, errors.New("user 7 not found"), &BugsnagMetadata{GroupingHash: "explicit"})
+
+	first, second := sentEvent(t, doer, 0)["groupingHash"], sentEvent(t, doer, 1)["groupingHash"]
+	if first != "user <n> not found" || first != second {
+		t.Errorf("expected both messages to group as one template, got %v and %v", first, second)
+	}
+	if got := sentEvent(t, doer, 2)["groupingHash"]; got != "explicit" {
+		t.Errorf("expected an explicit GroupingHash to win, got %v", got)
+	}
+}
@@ -0,0 +1,106 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReportPanic reports a value returned by recover() as an unhandled
+// error, with a stack trace starting at the site of the panic:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			bugsnack.ReportPanic(ctx, er, r)
+//		}
+//	}()
+//
+// Errors are reported as they are, anything else is formatted with %v.
+// A nil er reports to the default reporter (see SetDefault).
+// Panics are grouped by the code location they happened at, as their
+// messages (e.g. "index out of range [3] with length 2") often vary.
+func ReportPanic(ctx context.Context, er ErrorReporter, recovered interface{}) {
+	if recovered == nil {
+		return
+	}
+	if er == nil {
+		er = Default()
+	}
+
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", recovered)
+	}
+
+	stack := panicStack()
+	er.Report(ctx, &panicError{error: err, stack: stack}, &BugsnagMetadata{
+		GroupingHash:   panicGroupingHash(stack),
+		Severity:       "error",
+		Unhandled:      true,
+		SeverityReason: PanicReason(),
+	})
+}
+
+// panicError carries the stack of the goroutine at the time of a panic
+type panicError struct {
+	error
+	stack errors.StackTrace
+}
+
+func (pe *panicError) StackTrace() errors.StackTrace { return pe.stack }
+
+func (pe *panicError) Cause() error { return pe.error }
+
+func (pe *panicError) Unwrap() error { return pe.error }
+
+// panicStack returns the current stack, trimmed so that it starts
+// just below runtime.gopanic
+func panicStack() errors.StackTrace {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+
+	for i, pc := range pcs {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+			pcs = pcs[i+1:]
+			break
+		}
+	}
+
+	stack := make(errors.StackTrace, len(pcs))
+	for i, pc := range pcs {
+		stack[i] = errors.Frame(pc)
+	}
+	return stack
+}
+
+// panicGroupingHash identifies the top frame of stack in the project,
+// or else outside of the runtime, so that panics at the same place
+// group together, and those raised inside the standard library (e.g.
+// by regexp.MustCompile) group by the code calling it
+func panicGroupingHash(stack errors.StackTrace) string {
+	pcs := make([]uintptr, len(stack))
+	for i, f := range stack {
+		pcs[i] = uintptr(f)
+	}
+	frames := callersFrames(pcs)
+
+	for _, f := range frames {
+		if isProjectFrame(f) {
+			return panicSite(f)
+		}
+	}
+	for _, f := range frames {
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			return panicSite(f)
+		}
+	}
+	return ""
+}
+
+func panicSite(f stackFrame) string {
+	return fmt.Sprintf("panic at %s (%s:%d)", f.Function, path.Base(f.File), f.Line)
+}
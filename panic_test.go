@@ -0,0 +1,130 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type panicValue struct {
+	Code int
+}
+
+func panicWith(er ErrorReporter, v interface{}) {
+	defer func() {
+		ReportPanic(context.Background(), er, recover())
+	}()
+	panic(v)
+}
+
+func TestReportPanic(t *testing.T) {
+	original := errors.New("original error")
+
+	for _, tc := range []struct {
+		recovered interface{}
+		message   string
+	}{
+		{original, "original error"},
+		{"something broke", "panic: something broke"},
+		{panicValue{Code: 42}, "panic: {42}"},
+	} {
+		rr := &recordingReporter{}
+		panicWith(rr, tc.recovered)
+
+		if len(rr.errs) != 1 {
+			t.Fatalf("expected one report for %v, got %d", tc.recovered, len(rr.errs))
+		}
+		if rr.errs[0].Error() != tc.message {
+			t.Errorf("expected message %q, got %q", tc.message, rr.errs[0])
+		}
+		if md := rr.metadata[0]; !md.Unhandled || md.Severity != "error" {
+			t.Errorf("expected an unhandled error, got %+v", md)
+		}
+
+		frame := rr.errs[0].(stackTracer).StackTrace()[0]
+		if fn := fmt.Sprintf("%n", frame); fn != "panicWith" {
+			t.Errorf("expected the stack to start at the panic site, got %s", fn)
+		}
+	}
+
+	rr := &recordingReporter{}
+	panicWith(rr, original)
+	if !errors.Is(rr.errs[0], original) {
+		t.Errorf("expected the recovered error to be preserved")
+	}
+
+	ReportPanic(context.Background(), rr, nil)
+	if len(rr.errs) != 1 {
+		t.Errorf("expected nothing to be reported without a panic")
+	}
+}
+
+func indexPanic(er ErrorReporter, xs []int, i int) int {
+	defer func() {
+		ReportPanic(context.Background(), er, recover())
+	}()
+	return xs[i]
+}
+
+func otherIndexPanic(er ErrorReporter, xs []int, i int) int {
+	defer func() {
+		ReportPanic(context.Background(), er, recover())
+	}()
+	return xs[i]
+}
+
+func TestPanicGrouping(t *testing.T) {
+	rr := &recordingReporter{}
+	indexPanic(rr, []int{1, 2}, 3)
+	indexPanic(rr, []int{1}, 5)
+	otherIndexPanic(rr, nil, 0)
+
+	if rr.errs[0].Error() == rr.errs[1].Error() {
+		t.Fatalf("expected runtime messages to differ, got %q twice", rr.errs[0])
+	}
+	first, second, other := rr.metadata[0].GroupingHash, rr.metadata[1].GroupingHash, rr.metadata[2].GroupingHash
+	if first == "" || first != second {
+		t.Errorf("expected panics at the same site to group together, got %q and %q", first, second)
+	}
+	if !strings.Contains(first, "indexPanic") || !strings.Contains(first, "panic_test.go") {
+		t.Errorf("expected the grouping hash to name the panic site, got %q", first)
+	}
+	if other == first {
+		t.Errorf("expected panics at different sites to group apart, got %q", other)
+	}
+}
+
+func compilePanic(er ErrorReporter, expr string) {
+	defer func() {
+		ReportPanic(context.Background(), er, recover())
+	}()
+	regexp.MustCompile(expr)
+}
+
+func otherCompilePanic(er ErrorReporter, expr string) {
+	defer func() {
+		ReportPanic(context.Background(), er, recover())
+	}()
+	regexp.MustCompile(expr)
+}
+
+func TestPanicGroupingInStandardLibrary(t *testing.T) {
+	rr := &recordingReporter{}
+	compilePanic(rr, "(")
+	compilePanic(rr, "[")
+	otherCompilePanic(rr, "(")
+
+	first, second, other := rr.metadata[0].GroupingHash, rr.metadata[1].GroupingHash, rr.metadata[2].GroupingHash
+	if first == "" || first != second {
+		t.Errorf("expected panics at the same site to group together, got %q and %q", first, second)
+	}
+	if !strings.Contains(first, "compilePanic") || strings.Contains(first, "regexp.") {
+		t.Errorf("expected the grouping hash to name the calling code, got %q", first)
+	}
+	if other == first {
+		t.Errorf("expected panics from different callers to group apart, got %q", other)
+	}
+}
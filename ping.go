@@ -0,0 +1,59 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrUnauthorized is returned by Ping when the API key is rejected
+var ErrUnauthorized = errors.New("bugsnack: API key rejected")
+
+// A Pinger is a reporter which can check that errors would get
+// through, e.g. for a readiness probe
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping pings er if it is a Pinger. Reporters which can't be pinged
+// are assumed to be fine.
+func Ping(ctx context.Context, er ErrorReporter) error {
+	if p, ok := er.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// Ping sends bugsnag a payload without any events, checking that it
+// can be reached and accepts the API key, without creating an event
+func (er *BugsnagReporter) Ping(ctx context.Context) error {
+	body, err := json.Marshal(er.newPayload())
+	if err != nil {
+		return err
+	}
+
+	req, err := er.request(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := er.doer().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, defaultResponseReadLimit))
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("bugsnack: ping failed: %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+	return nil
+}
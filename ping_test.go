@@ -0,0 +1,48 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			APIKey string        `json:"apiKey"`
+			Events []interface{} `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || len(payload.Events) != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if payload.APIKey != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	er := &BugsnagReporter{APIKey: "valid", Endpoint: srv.URL, Doer: srv.Client()}
+	if err := er.Ping(context.Background()); err != nil {
+		t.Errorf("expected a successful ping, got %v", err)
+	}
+
+	er.APIKey = "invalid"
+	if err := Ping(context.Background(), er); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+
+	srv.Close()
+	if err := er.Ping(context.Background()); err == nil {
+		t.Error("expected an error once the server is gone")
+	}
+
+	if err := Ping(context.Background(), &WriterReporter{}); err != nil {
+		t.Errorf("expected reporters which can't ping to be fine, got %v", err)
+	}
+}
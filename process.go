@@ -0,0 +1,92 @@
+package bugsnack
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+)
+
+// A ProcessReporter attaches how the process was invoked under a
+// "process" tab: its arguments, with the values of flags not in
+// AllowFlags filtered out, and the current values of the allowed flags
+// in FlagSet. Since it cannot tell boolean flags from others, an
+// argument following a flag without "=value" is taken as its value.
+type ProcessReporter struct {
+	Reporter   ErrorReporter
+	AllowFlags []string
+
+	// Args defaults to os.Args, and FlagSet to flag.CommandLine
+	Args    []string
+	FlagSet *flag.FlagSet
+}
+
+// Report sends the error on with the process tab added
+func (pr *ProcessReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	args := pr.Args
+	if args == nil {
+		args = os.Args
+	}
+	flags := pr.FlagSet
+	if flags == nil {
+		flags = flag.CommandLine
+	}
+
+	values := map[string]interface{}{}
+	for _, name := range pr.AllowFlags {
+		if f := flags.Lookup(name); f != nil {
+			values[name] = f.Value.String()
+		}
+	}
+
+	process := &BugsnagMetadata{EventMetadata: &map[string]interface{}{
+		"process": map[string]interface{}{
+			"args":  scrubArgs(args, pr.AllowFlags),
+			"flags": values,
+		},
+	}}
+	pr.Reporter.Report(ctx, err, withMetadata(metadata, mergeMetadata(process, metadataFrom(metadata)))...)
+}
+
+// Flush flushes the underlying Reporter
+func (pr *ProcessReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, pr.Reporter)
+}
+
+// scrubArgs returns a copy of args with the values of flags not in
+// allow replaced by a marker
+func scrubArgs(args, allow []string) []string {
+	allowed := make(map[string]bool, len(allow))
+	for _, name := range allow {
+		allowed[name] = true
+	}
+
+	scrubbed := make([]string, len(args))
+	copy(scrubbed, args)
+	for i := 1; i < len(scrubbed); i++ {
+		arg := scrubbed[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		name, _, hasValue := strings.Cut(name, "=")
+		if allowed[name] {
+			// an allowed flag's value is kept, but a flag following a
+			// boolean one is still scrubbed
+			if !hasValue && i+1 < len(scrubbed) && !strings.HasPrefix(scrubbed[i+1], "-") {
+				i++
+			}
+			continue
+		}
+		if hasValue {
+			scrubbed[i] = arg[:strings.Index(arg, "=")+1] + filteredMarker
+		} else if i+1 < len(scrubbed) && !strings.HasPrefix(scrubbed[i+1], "-") {
+			i++
+			scrubbed[i] = filteredMarker
+		}
+	}
+	return scrubbed
+}
@@ -0,0 +1,66 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestProcessReporter(t *testing.T) {
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags.String("addr", ":8080", "")
+	flags.String("db-password", "", "")
+	flags.Bool("v", false, "")
+
+	args := []string{"app", "-addr", ":9090", "--db-password=hunter2", "-token", "s3cret", "-v", "serve"}
+	if err := flags.Parse([]string{"-addr", ":9090", "--db-password=hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := &recordingReporter{}
+	pr := &ProcessReporter{Reporter: rr, AllowFlags: []string{"addr", "v"}, Args: args, FlagSet: flags}
+	pr.Report(context.Background(), errors.New("process test"), &BugsnagMetadata{Severity: "warning"})
+
+	md := rr.metadata[0]
+	if md.Severity != "warning" {
+		t.Errorf("expected the per-call metadata to be kept, got %+v", md)
+	}
+	tab := (*md.EventMetadata)["process"].(map[string]interface{})
+
+	want := []string{"app", "-addr", ":9090", "--db-password=[FILTERED]", "-token", "[FILTERED]", "-v", "serve"}
+	if got := tab["args"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected args\n%q\ngot\n%q", want, got)
+	}
+	wantFlags := map[string]interface{}{"addr": ":9090", "v": "false"}
+	if got := tab["flags"]; !reflect.DeepEqual(got, wantFlags) {
+		t.Errorf("expected flags %v, got %v", wantFlags, got)
+	}
+}
+
+func TestScrubArgsAfterAllowedBoolFlag(t *testing.T) {
+	for _, test := range []struct {
+		args, allow, want []string
+	}{
+		{
+			[]string{"app", "-verbose", "-password", "hunter2"},
+			[]string{"verbose"},
+			[]string{"app", "-verbose", "-password", "[FILTERED]"},
+		},
+		{
+			[]string{"app", "-v", "--token", "abc"},
+			[]string{"v"},
+			[]string{"app", "-v", "--token", "[FILTERED]"},
+		},
+		{
+			[]string{"app", "-v", "--token=abc", "-addr", ":80"},
+			[]string{"v", "addr"},
+			[]string{"app", "-v", "--token=[FILTERED]", "-addr", ":80"},
+		},
+	} {
+		if got := scrubArgs(test.args, test.allow); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("scrubArgs(%q, %q): expected\n%q\ngot\n%q", test.args, test.allow, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package bugsnack
+
+import (
+	"context"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// A ProfileReporter writes a heap profile when an error matches (e.g.
+// an out-of-memory error), and attaches its path under a "profile" tab.
+// At most one profile is written per MinInterval.
+type ProfileReporter struct {
+	Reporter ErrorReporter
+	Match    func(err error) bool
+
+	// Dir is where profiles are written, defaulting to os.TempDir()
+	Dir         string
+	MinInterval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// Report sends the error on, with a heap profile if it matches
+func (pr *ProfileReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	if err == nil || pr.Match == nil || !pr.Match(err) || !pr.due(now()) {
+		pr.Reporter.Report(ctx, err, metadata...)
+		return
+	}
+
+	tab := map[string]interface{}{}
+	if path, profileErr := pr.writeHeapProfile(); profileErr != nil {
+		tab["heapError"] = profileErr.Error()
+	} else {
+		tab["heap"] = path
+	}
+	profile := &BugsnagMetadata{EventMetadata: &map[string]interface{}{"profile": tab}}
+	pr.Reporter.Report(ctx, err, withMetadata(metadata, mergeMetadata(profile, metadataFrom(metadata)))...)
+}
+
+// due reports whether a profile may be written at t
+func (pr *ProfileReporter) due(t time.Time) bool {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if !pr.last.IsZero() && t.Sub(pr.last) < pr.MinInterval {
+		return false
+	}
+	pr.last = t
+	return true
+}
+
+func (pr *ProfileReporter) writeHeapProfile() (path string, err error) {
+	f, err := os.CreateTemp(pr.Dir, "heap-*.pprof")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return f.Name(), pprof.Lookup("heap").WriteTo(f, 0)
+}
+
+// Flush flushes the underlying Reporter
+func (pr *ProfileReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, pr.Reporter)
+}
@@ -0,0 +1,62 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileReporter(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	dir := t.TempDir()
+	rr := &recordingReporter{}
+	pr := &ProfileReporter{
+		Reporter: rr,
+		Match: func(err error) bool {
+			return strings.Contains(err.Error(), "out of memory")
+		},
+		Dir:         dir,
+		MinInterval: time.Minute,
+	}
+	ctx := context.Background()
+
+	pr.Report(ctx, errors.New("connection refused"))
+	pr.Report(ctx, errors.New("allocating buffer: out of memory"), &BugsnagMetadata{Severity: "warning"})
+	pr.Report(ctx, errors.New("allocating buffer: out of memory"))
+	clock = clock.Add(time.Minute)
+	pr.Report(ctx, errors.New("allocating buffer: out of memory"))
+
+	if len(rr.errs) != 4 {
+		t.Fatalf("expected every error to be reported, got %d", len(rr.errs))
+	}
+	if md := rr.metadata[0]; md != nil {
+		t.Errorf("expected no profile for other errors, got %+v", md)
+	}
+	if md := rr.metadata[2]; md != nil {
+		t.Errorf("expected no profile within MinInterval, got %+v", md)
+	}
+
+	md := rr.metadata[1]
+	if md.Severity != "warning" {
+		t.Errorf("expected the metadata to be kept, got %+v", md)
+	}
+	path, _ := (*md.EventMetadata)["profile"].(map[string]interface{})["heap"].(string)
+	if filepath.Dir(path) != dir {
+		t.Fatalf("expected a profile in %s, got %q", dir, path)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("expected a heap profile to be written, got %v", err)
+	}
+
+	profiles, _ := filepath.Glob(filepath.Join(dir, "heap-*.pprof"))
+	if len(profiles) != 2 {
+		t.Errorf("expected 2 profiles, got %v", profiles)
+	}
+}
@@ -0,0 +1,65 @@
+package bugsnack
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A Record is a single error read by Pump, letting processes which
+// are not written in Go report errors through a bugsnack sidecar
+type Record struct {
+	Message      string                 `json:"message"`
+	ErrorClass   string                 `json:"errorClass"`
+	Context      string                 `json:"context"`
+	GroupingHash string                 `json:"groupingHash"`
+	Severity     string                 `json:"severity"`
+	MetaData     map[string]interface{} `json:"metaData"`
+}
+
+// maxRecordSize bounds a single line read by Pump
+const maxRecordSize = 1 << 20
+
+// Pump reads newline-delimited JSON Records from r and reports each
+// one to er, until r is exhausted or ctx is done. Lines which cannot
+// be decoded are reported as errors themselves.
+func Pump(ctx context.Context, r io.Reader, er ErrorReporter) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			er.Report(ctx, fmt.Errorf("bugsnack: invalid record on line %d: %v", line, err))
+			continue
+		}
+		er.Report(ctx, errors.New(rec.Message), rec.metadata())
+	}
+	return scanner.Err()
+}
+
+func (rec *Record) metadata() *BugsnagMetadata {
+	metadata := &BugsnagMetadata{
+		ErrorClass:   rec.ErrorClass,
+		Context:      rec.Context,
+		GroupingHash: rec.GroupingHash,
+		Severity:     rec.Severity,
+	}
+	if metadata.ErrorClass == "" {
+		metadata.ErrorClass = "error"
+	}
+	if rec.MetaData != nil {
+		metadata.EventMetadata = &rec.MetaData
+	}
+	return metadata
+}
@@ -0,0 +1,47 @@
+package bugsnack
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type recordingReporter struct {
+	errs     []error
+	metadata []*BugsnagMetadata
+}
+
+func (rr *recordingReporter) Report(_ context.Context, err error, metadata ...interface{}) {
+	rr.errs = append(rr.errs, err)
+	rr.metadata = append(rr.metadata, metadataFrom(metadata))
+}
+
+func TestPump(t *testing.T) {
+	input := strings.Join([]string{
+		`{"message":"connection refused","errorClass":"ECONNREFUSED","severity":"warning","context":"worker.js"}`,
+		``,
+		`{"message":"undefined is not a function","metaData":{"request":{"path":"/users"}}}`,
+		`not json`,
+	}, "\n")
+
+	rr := &recordingReporter{}
+	if err := Pump(context.Background(), strings.NewReader(input), rr); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rr.errs) != 3 {
+		t.Fatalf("expected 3 reports, got %d", len(rr.errs))
+	}
+	if rr.errs[0].Error() != "connection refused" {
+		t.Errorf("unexpected message %q", rr.errs[0])
+	}
+	if md := rr.metadata[0]; md.ErrorClass != "ECONNREFUSED" || md.Severity != "warning" || md.Context != "worker.js" {
+		t.Errorf("unexpected metadata %+v", md)
+	}
+	if md := rr.metadata[1]; md.ErrorClass != "error" || (*md.EventMetadata)["request"] == nil {
+		t.Errorf("unexpected metadata %+v", md)
+	}
+	if !strings.Contains(rr.errs[2].Error(), "line 4") {
+		t.Errorf("expected the invalid line to be reported, got %q", rr.errs[2])
+	}
+}
@@ -0,0 +1,223 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// queueExt marks complete payloads in a DiskQueue's directory;
+// anything else (e.g. a write cut short by a crash) is ignored
+const queueExt = ".payload"
+
+// An UndeliverableError wraps the error of a payload which was
+// rejected outright (e.g. as malformed or too large), so that sending
+// it again is pointless
+type UndeliverableError struct {
+	Err error
+}
+
+func (ue *UndeliverableError) Error() string { return ue.Err.Error() }
+
+func (ue *UndeliverableError) Cause() error { return ue.Err }
+
+func (ue *UndeliverableError) Unwrap() error { return ue.Err }
+
+// A DiskQueue holds encoded payloads in a directory until they are
+// delivered, so that they survive the process dying. Payloads are
+// delivered oldest first, and once the directory holds more than
+// MaxBytes (if set), the oldest are dropped to make room. Each payload
+// keeps the idempotency key it was queued with, so that the backend
+// can tell a replay from a new report.
+type DiskQueue struct {
+	Dir      string
+	MaxBytes int64
+
+	mu      sync.Mutex
+	seq     uint64
+	started bool
+
+	// drainMu makes sure each payload is delivered by a single Drain
+	drainMu sync.Mutex
+}
+
+type queuedPayload struct {
+	path string
+	key  string
+	size int64
+}
+
+// Enqueue writes body to the queue, along with its idempotency key,
+// which may only hold letters, digits and dashes
+func (q *DiskQueue) Enqueue(key string, body []byte) error {
+	if strings.Trim(key, "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") != "" {
+		return fmt.Errorf("bugsnack: invalid idempotency key %q", key)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.started {
+		if err := q.start(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.CreateTemp(q.Dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	q.seq++
+	name := fmt.Sprintf("%020d", q.seq)
+	if key != "" {
+		name += "." + key
+	}
+	if err := os.Rename(f.Name(), filepath.Join(q.Dir, name+queueExt)); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return q.trim()
+}
+
+// start creates the directory if needed, and carries on numbering
+// payloads after those left behind by an earlier process
+func (q *DiskQueue) start() error {
+	if err := os.MkdirAll(q.Dir, 0o700); err != nil {
+		return err
+	}
+	payloads, err := q.list()
+	if err != nil {
+		return err
+	}
+	if n := len(payloads); n > 0 {
+		seq, _ := splitPayloadName(filepath.Base(payloads[n-1].path))
+		if q.seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return err
+		}
+	}
+	q.started = true
+	return nil
+}
+
+// splitPayloadName splits the name of a payload's file, e.g.
+// "00000000000000000042.<key>.payload", into its number and key
+func splitPayloadName(name string) (seq, key string) {
+	seq = strings.TrimSuffix(name, queueExt)
+	if i := strings.IndexByte(seq, '.'); i >= 0 {
+		seq, key = seq[:i], seq[i+1:]
+	}
+	return seq, key
+}
+
+// trim drops the oldest payloads until the queue fits in MaxBytes
+func (q *DiskQueue) trim() error {
+	if q.MaxBytes <= 0 {
+		return nil
+	}
+	payloads, err := q.list()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, p := range payloads {
+		total += p.size
+	}
+	for _, p := range payloads {
+		if total <= q.MaxBytes {
+			break
+		}
+		if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= p.size
+	}
+	return nil
+}
+
+// list returns the queued payloads, oldest first
+func (q *DiskQueue) list() ([]queuedPayload, error) {
+	entries, err := os.ReadDir(q.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var payloads []queuedPayload
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != queueExt {
+			continue
+		}
+		info, err := entry.Info()
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, key := splitPayloadName(entry.Name())
+		payloads = append(payloads, queuedPayload{path: filepath.Join(q.Dir, entry.Name()), key: key, size: info.Size()})
+	}
+	// names are zero-padded, so they sort in the order they were queued
+	sort.Slice(payloads, func(i, j int) bool { return payloads[i].path < payloads[j].path })
+	return payloads, nil
+}
+
+// Drain passes each queued payload and its key to send, oldest first,
+// removing it once sent. A payload send fails with an
+// *UndeliverableError is dropped too, and draining carries on, to
+// return the first such error at the end. On any other error it stops,
+// leaving the rest queued.
+func (q *DiskQueue) Drain(ctx context.Context, send func(ctx context.Context, key string, body []byte) error) error {
+	q.drainMu.Lock()
+	defer q.drainMu.Unlock()
+
+	q.mu.Lock()
+	payloads, err := q.list()
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var undeliverable error
+	for _, p := range payloads {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		body, err := os.ReadFile(p.path)
+		if os.IsNotExist(err) {
+			// dropped to make room while we were busy
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := send(ctx, p.key, body); err != nil {
+			if _, ok := err.(*UndeliverableError); !ok {
+				return err
+			}
+			if undeliverable == nil {
+				undeliverable = err
+			}
+		}
+		if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return undeliverable
+}
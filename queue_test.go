@@ -0,0 +1,160 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForQueue waits for er's background delivery to finish
+func waitForQueue(t *testing.T, er *BugsnagReporter) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		er.queueMu.Lock()
+		draining := er.draining
+		er.queueMu.Unlock()
+		if !draining {
+			return
+		}
+	}
+	t.Fatal("background delivery did not finish")
+}
+
+func TestQueueSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// the first process cannot reach bugsnag before it dies
+	down := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   (&MockDoer{}).FailWith(errors.New("bugsnag is down")),
+		Backup: &recordingReporter{},
+		Queue:  &DiskQueue{Dir: dir},
+	}
+	down.Report(ctx, errors.New("first"))
+	waitForQueue(t, down)
+	down.Report(ctx, errors.New("second"))
+	waitForQueue(t, down)
+	if err := down.Flush(ctx); err == nil {
+		t.Fatal("expected flushing to fail while bugsnag is down")
+	}
+
+	doer := &MockDoer{}
+	up := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Queue: &DiskQueue{Dir: dir}}
+	if err := up.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	up.Report(ctx, errors.New("third"))
+	waitForQueue(t, up)
+
+	var messages []string
+	for i := range doer.Bodies() {
+		exception := sentEvent(t, doer, i)["exceptions"].([]interface{})[0].(map[string]interface{})
+		messages = append(messages, exception["message"].(string))
+	}
+	if got := strings.Join(messages, ","); got != "first,second,third" {
+		t.Errorf("expected the queued reports to be replayed in order, got %s", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected the queue to be empty, found %d files", len(entries))
+	}
+}
+
+func TestQueueMaxBytes(t *testing.T) {
+	q := &DiskQueue{Dir: t.TempDir(), MaxBytes: 25}
+	for _, body := range []string{"oldest....", "middle....", "newest...."} {
+		if err := q.Enqueue("", []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var sent []string
+	err := q.Drain(context.Background(), func(_ context.Context, _ string, body []byte) error {
+		sent = append(sent, string(body))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(sent, ","); got != "middle....,newest...." {
+		t.Errorf("expected the oldest payload to be dropped, got %s", got)
+	}
+}
+
+func TestQueueReplay(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	failing := (&MockDoer{}).FailWith(errors.New("bugsnag is down"))
+	down := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   failing,
+		Backup: &recordingReporter{},
+		Queue:  &DiskQueue{Dir: dir},
+	}
+	down.Report(ctx, errors.New("too large"))
+	waitForQueue(t, down)
+	down.Report(ctx, errors.New("fine"))
+	waitForQueue(t, down)
+
+	// the first payload is rejected, which mustn't hold up the second
+	doer := (&MockDoer{}).RespondWith(http.StatusRequestEntityTooLarge, "").RespondWith(http.StatusOK, "")
+	backup := &recordingReporter{}
+	up := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Backup: backup, Queue: &DiskQueue{Dir: dir}}
+	up.ReplayQueue()
+	waitForQueue(t, up)
+
+	requests := doer.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected both payloads to be sent on startup, got %d", len(requests))
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected the queue to be empty, found %d files", len(entries))
+	}
+	if len(backup.errs) != 1 || !strings.Contains(backup.errs[0].Error(), "413") {
+		t.Errorf("expected the rejection to be reported, got %v", backup.errs)
+	}
+
+	first := failing.Requests()[0].Header.Get("Idempotency-Key")
+	if key := requests[0].Header.Get("Idempotency-Key"); first == "" || key != first {
+		t.Errorf("expected the replay to keep the key %q of the first attempt, got %q", first, key)
+	}
+	if key := requests[1].Header.Get("Idempotency-Key"); key == "" || key == first {
+		t.Errorf("expected another report to have its own key, got %q", key)
+	}
+}
+
+func TestQueueDrainSkipsUndeliverable(t *testing.T) {
+	q := &DiskQueue{Dir: t.TempDir()}
+	for _, body := range []string{"malformed", "fine"} {
+		if err := q.Enqueue("key-"+body, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Enqueue("../escape", nil); err == nil {
+		t.Error("expected a key which isn't a plain name to be refused")
+	}
+
+	var keys []string
+	rejected := &UndeliverableError{Err: errors.New("400 Bad Request")}
+	err := q.Drain(context.Background(), func(_ context.Context, key string, body []byte) error {
+		keys = append(keys, key)
+		if string(body) == "malformed" {
+			return rejected
+		}
+		return nil
+	})
+	if err != rejected {
+		t.Errorf("expected the rejection once done, got %v", err)
+	}
+	if got := strings.Join(keys, ","); got != "key-malformed,key-fine" {
+		t.Errorf("expected every payload to be sent with its key, got %s", got)
+	}
+	if payloads, _ := q.list(); len(payloads) != 0 {
+		t.Errorf("expected the queue to be empty, found %d payloads", len(payloads))
+	}
+}
@@ -0,0 +1,32 @@
+package bugsnack
+
+// A Region is where bugsnag stores events, for data residency
+type Region string
+
+// The regions bugsnag is hosted in
+const (
+	RegionUS Region = "us"
+	RegionEU Region = "eu"
+)
+
+// NotifyEndpoint returns the URL events are posted to in r,
+// defaulting to the US
+func (r Region) NotifyEndpoint() string {
+	if r == RegionEU {
+		return "https://notify.insighthub.smartbear.com"
+	}
+	return defaultEndpoint
+}
+
+// SessionsEndpoint returns the URL sessions are posted to in r,
+// defaulting to the US
+func (r Region) SessionsEndpoint() string {
+	if r == RegionEU {
+		return "https://sessions.insighthub.smartbear.com"
+	}
+	return "https://sessions.bugsnag.com"
+}
+
+func (r Region) valid() bool {
+	return r == "" || r == RegionUS || r == RegionEU
+}
@@ -0,0 +1,40 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegion(t *testing.T) {
+	for _, tc := range []struct {
+		region   Region
+		endpoint string
+		notify   string
+		sessions string
+	}{
+		{"", "", "https://notify.bugsnag.com", "https://sessions.bugsnag.com"},
+		{RegionUS, "", "https://notify.bugsnag.com", "https://sessions.bugsnag.com"},
+		{RegionEU, "", "https://notify.insighthub.smartbear.com", "https://sessions.insighthub.smartbear.com"},
+		{RegionEU, "https://bugsnag.internal", "https://bugsnag.internal", "https://sessions.insighthub.smartbear.com"},
+	} {
+		doer := &MockDoer{}
+		er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, Region: tc.region, Endpoint: tc.endpoint}
+		er.Report(context.Background(), errors.New("region test"))
+
+		if url := doer.Requests()[0].URL.String(); url != tc.notify {
+			t.Errorf("region %q: expected events to go to %s, got %s", tc.region, tc.notify, url)
+		}
+		if sessions := tc.region.SessionsEndpoint(); sessions != tc.sessions {
+			t.Errorf("region %q: expected sessions endpoint %s, got %s", tc.region, tc.sessions, sessions)
+		}
+	}
+
+	if _, err := New(Config{APIKey: testAPIKey, Region: "mars"}); err == nil {
+		t.Error("expected an unknown region to be rejected")
+	}
+	er, err := New(Config{APIKey: testAPIKey, Region: RegionEU})
+	if err != nil || er.endpoint() != "https://notify.insighthub.smartbear.com" {
+		t.Errorf("expected New to use the EU endpoint, got %v (%v)", er, err)
+	}
+}
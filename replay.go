@@ -0,0 +1,99 @@
+package bugsnack
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// defaultReplayInterval keeps replays well within bugsnag's quotas
+const defaultReplayInterval = 100 * time.Millisecond
+
+// A Replayer re-reports archived errors, waiting Interval between
+// reports so that a large archive does not exhaust the quota of the
+// service it is replayed to
+type Replayer struct {
+	Interval time.Duration
+}
+
+// Replay re-reports the errors archived in r to er, at most ten per
+// second. See Replayer.Replay.
+func Replay(ctx context.Context, r io.Reader, er ErrorReporter) error {
+	return (&Replayer{Interval: defaultReplayInterval}).Replay(ctx, r, er)
+}
+
+// Replay reads errors written by a JSONReporter (e.g. used as a
+// BugsnagReporter's Archive) from r, and reports each to er as it was
+// originally reported, keeping the time it occurred. Lines which
+// cannot be decoded are reported as errors themselves, like Pump does.
+func (rp *Replayer) Replay(ctx context.Context, r io.Reader, er ErrorReporter) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	reported := false
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if reported && rp.Interval > 0 {
+			timer := time.NewTimer(rp.Interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		reported = true
+
+		var archived jsonLine
+		if err := json.Unmarshal(scanner.Bytes(), &archived); err != nil {
+			er.Report(ctx, fmt.Errorf("bugsnack: invalid archived error on line %d: %v", line, err))
+			continue
+		}
+		er.Report(ctx, archived.err(), archived.metadata())
+	}
+	return scanner.Err()
+}
+
+// err recreates an archived error, with the time it occurred
+func (l *jsonLine) err() error {
+	err := errors.New(l.Msg)
+	if t, parseErr := time.Parse(eventTimeFormat, l.Time); parseErr == nil {
+		return &timestampError{error: err, t: t}
+	}
+	return err
+}
+
+func (l *jsonLine) metadata() *BugsnagMetadata {
+	severity := l.Level
+	if severity == "warn" {
+		severity = "warning"
+	}
+	eventMetadata := map[string]interface{}{}
+	if l.MetaData != nil {
+		for name, tab := range *l.MetaData {
+			eventMetadata[name] = tab
+		}
+	}
+	if l.Stack != "" {
+		eventMetadata["archive"] = map[string]interface{}{"stack": l.Stack}
+	}
+
+	metadata := &BugsnagMetadata{
+		ErrorClass:   l.Error,
+		Severity:     severity,
+		Context:      l.Context,
+		GroupingHash: l.GroupingHash,
+	}
+	if len(eventMetadata) > 0 {
+		metadata.EventMetadata = &eventMetadata
+	}
+	return metadata
+}
@@ -0,0 +1,82 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	// archive a few errors the way a BugsnagReporter's Archive would
+	var archive bytes.Buffer
+	jr := &JSONReporter{Writer: &archive}
+	jr.Report(context.Background(), errors.New("bugsnag was down"), &BugsnagMetadata{
+		Severity:      "warning",
+		Context:       "worker",
+		EventMetadata: &map[string]interface{}{"job": map[string]interface{}{"id": "42"}},
+	})
+	clock = clock.Add(time.Minute)
+	jr.Report(context.Background(), errors.New("still down"), &BugsnagMetadata{ErrorClass: "outage"})
+	archive.WriteString("\nnot json\n")
+
+	rr := &recordingReporter{}
+	interval := 5 * time.Millisecond
+	start := time.Now()
+	if err := (&Replayer{Interval: interval}).Replay(context.Background(), &archive, rr); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("expected reports to be spaced out, took %s", elapsed)
+	}
+
+	if len(rr.errs) != 3 {
+		t.Fatalf("expected each archived line to be reported, got %v", rr.errs)
+	}
+	if rr.errs[0].Error() != "bugsnag was down" || rr.errs[1].Error() != "still down" {
+		t.Errorf("expected the archived messages, got %v", rr.errs)
+	}
+	if at := occurredAt(rr.errs[1], time.Time{}); !at.Equal(clock) {
+		t.Errorf("expected the original time to be kept, got %s", at)
+	}
+
+	first := rr.metadata[0]
+	if first.Severity != "warning" || first.Context != "worker" || first.ErrorClass != "*errors.errorString" {
+		t.Errorf("expected the archived metadata, got %+v", first)
+	}
+	if _, ok := (*first.EventMetadata)["job"]; !ok {
+		t.Errorf("expected the archived tabs, got %v", *first.EventMetadata)
+	}
+	if _, ok := (*first.EventMetadata)["archive"].(map[string]interface{})["stack"]; !ok {
+		t.Errorf("expected the archived stack, got %v", *first.EventMetadata)
+	}
+	if rr.metadata[1].ErrorClass != "outage" {
+		t.Errorf("expected the archived class, got %+v", rr.metadata[1])
+	}
+}
+
+func TestReplayCanceled(t *testing.T) {
+	var archive bytes.Buffer
+	jr := &JSONReporter{Writer: &archive}
+	for i := 0; i < 3; i++ {
+		jr.Report(context.Background(), errors.New("archived"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rr := &recordingReporter{}
+	er := &TransformReporter{Reporter: rr, Transform: func(err error, md []interface{}) (error, []interface{}) {
+		cancel()
+		return err, md
+	}}
+	if err := (&Replayer{Interval: time.Hour}).Replay(ctx, &archive, er); err != context.Canceled {
+		t.Errorf("expected the replay to stop when canceled, got %v", err)
+	}
+	if len(rr.errs) != 1 {
+		t.Errorf("expected a single report before canceling, got %d", len(rr.errs))
+	}
+}
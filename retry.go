@@ -0,0 +1,62 @@
+package bugsnack
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = time.Minute
+)
+
+// send delivers an encoded payload to bugsnag, retrying as configured.
+// Every attempt carries key as its Idempotency-Key header, so that the
+// backend can tell a retry from a new report.
+func (er *BugsnagReporter) send(ctx context.Context, key string, body []byte) error {
+	for attempt := 0; ; attempt++ {
+		retry, err := er.attempt(ctx, body, key)
+		if err == nil || !retry || attempt >= er.MaxRetries {
+			return err
+		}
+
+		timer := time.NewTimer(er.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns how long to wait before retrying after the given
+// (zero-based) attempt
+func (er *BugsnagReporter) backoff(attempt int) time.Duration {
+	d := er.RetryBackoff
+	if d <= 0 {
+		d = defaultRetryBackoff
+	}
+	for i := 0; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+
+	if !er.Jitter {
+		return d
+	}
+
+	er.randMu.Lock()
+	defer er.randMu.Unlock()
+	if er.rand == nil {
+		source := er.RandSource
+		if source == nil {
+			source = rand.NewSource(time.Now().UnixNano())
+		}
+		er.rand = rand.New(source)
+	}
+	return time.Duration(er.rand.Int63n(int64(d)))
+}
@@ -0,0 +1,106 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	doer := (&MockDoer{}).
+		FailWith(errors.New("connection reset")).
+		RespondWith(http.StatusServiceUnavailable, "").
+		RespondWith(http.StatusOK, "")
+	er := &BugsnagReporter{
+		APIKey:       testAPIKey,
+		Doer:         doer,
+		MaxRetries:   3,
+		RetryBackoff: time.Millisecond,
+		SignRequest: func(body []byte) (string, string) {
+			return "X-Signature", fmt.Sprintf("%x", sha256.Sum256(body))
+		},
+	}
+
+	if err := er.ReportE(context.Background(), errors.New("retry test")); err != nil {
+		t.Fatalf("expected delivery after retrying, got %v", err)
+	}
+
+	bodies := doer.Bodies()
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(bodies))
+	}
+	want := fmt.Sprintf("%x", sha256.Sum256(bodies[0]))
+	for i, req := range doer.Requests() {
+		if !bytes.Equal(bodies[i], bodies[0]) || req.Header.Get("X-Signature") != want {
+			t.Errorf("expected attempt %d to resend the same signed body", i)
+		}
+	}
+
+	doer = (&MockDoer{}).RespondWith(http.StatusBadRequest, "")
+	er.Doer = doer
+	if err := er.ReportE(context.Background(), errors.New("retry test")); err == nil {
+		t.Error("expected a 400 to fail")
+	}
+	if n := len(doer.Requests()); n != 1 {
+		t.Errorf("expected a 400 not to be retried, got %d attempts", n)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	base := 100 * time.Millisecond
+	plain := &BugsnagReporter{RetryBackoff: base}
+	jittered := &BugsnagReporter{RetryBackoff: base, Jitter: true, RandSource: rand.NewSource(42)}
+	same := &BugsnagReporter{RetryBackoff: base, Jitter: true, RandSource: rand.NewSource(42)}
+
+	distinct := map[time.Duration]bool{}
+	for attempt := 0; attempt < 6; attempt++ {
+		ceiling := base << uint(attempt)
+		if d := plain.backoff(attempt); d != ceiling {
+			t.Errorf("attempt %d: expected %v without jitter, got %v", attempt, ceiling, d)
+		}
+
+		d := jittered.backoff(attempt)
+		if d < 0 || d >= ceiling {
+			t.Errorf("attempt %d: expected jitter within [0, %v), got %v", attempt, ceiling, d)
+		}
+		if other := same.backoff(attempt); other != d {
+			t.Errorf("attempt %d: expected the same source to give %v, got %v", attempt, d, other)
+		}
+		distinct[d] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("expected jittered backoffs to vary, got %v", distinct)
+	}
+
+	if d := plain.backoff(20); d != maxRetryBackoff {
+		t.Errorf("expected the backoff to be capped at %v, got %v", maxRetryBackoff, d)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	doer := (&MockDoer{}).
+		RespondWith(http.StatusBadGateway, "").
+		RespondWith(http.StatusOK, "")
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, MaxRetries: 1, RetryBackoff: time.Millisecond}
+
+	er.Report(context.Background(), errors.New("first"))
+	er.Report(context.Background(), errors.New("second"))
+
+	requests := doer.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("expected a retry, then a single attempt, got %d requests", len(requests))
+	}
+	key := requests[0].Header.Get("Idempotency-Key")
+	if len(key) != 36 || requests[1].Header.Get("Idempotency-Key") != key {
+		t.Errorf("expected the same key across retries, got %q and %q", key, requests[1].Header.Get("Idempotency-Key"))
+	}
+	if next := requests[2].Header.Get("Idempotency-Key"); next == "" || next == key {
+		t.Errorf("expected a new key for a new report, got %q", next)
+	}
+}
@@ -0,0 +1,27 @@
+package bugsnack
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// A RuntimeTraceReporter logs each error to the execution trace (under
+// the "error" category) while one is being recorded, so that errors
+// show up in `go tool trace` next to what the program was doing
+type RuntimeTraceReporter struct {
+	Reporter ErrorReporter
+}
+
+// Report logs the error to the execution trace, then sends it on to
+// the underlying Reporter
+func (rr *RuntimeTraceReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	if trace.IsEnabled() && err != nil {
+		trace.Log(ctx, "error", err.Error())
+	}
+	rr.Reporter.Report(ctx, err, metadata...)
+}
+
+// Flush flushes the underlying Reporter
+func (rr *RuntimeTraceReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, rr.Reporter)
+}
@@ -0,0 +1,34 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/trace"
+	"testing"
+)
+
+func TestRuntimeTraceReporter(t *testing.T) {
+	rr := &recordingReporter{}
+	er := &RuntimeTraceReporter{Reporter: rr}
+	ctx := context.Background()
+
+	er.Report(ctx, errors.New("before tracing"))
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("cannot trace: %v", err)
+	}
+	er.Report(ctx, errors.New("disk quota exceeded"))
+	trace.Stop()
+
+	if len(rr.errs) != 2 {
+		t.Errorf("expected both errors to be passed on, got %v", rr.errs)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("disk quota exceeded")) {
+		t.Errorf("expected the error to be logged to the trace")
+	}
+	if bytes.Contains(buf.Bytes(), []byte("before tracing")) {
+		t.Errorf("expected nothing to be logged before tracing started")
+	}
+}
@@ -0,0 +1,49 @@
+package bugsnack
+
+import (
+	"context"
+	"math/rand"
+)
+
+// A SamplingReporter only passes on a fraction of errors, chosen by
+// their severity. Errors deserve to be seen every time, but warnings
+// and info can often be sampled heavily to save quota.
+type SamplingReporter struct {
+	Reporter ErrorReporter
+
+	// Rates maps a severity to the fraction (0 to 1) of errors with
+	// that severity which are reported. Severities not in the map
+	// are always reported.
+	Rates map[string]float64
+
+	// Rand returns a number in [0, 1), defaulting to rand.Float64
+	Rand func() float64
+}
+
+// Report sends the error on to the underlying Reporter if it
+// is sampled
+func (sr *SamplingReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+	if !sr.sampled(severity(md)) {
+		return
+	}
+	sr.Reporter.Report(ctx, err, metadata...)
+}
+
+// Flush flushes the underlying Reporter
+func (sr *SamplingReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, sr.Reporter)
+}
+
+func (sr *SamplingReporter) sampled(severity string) bool {
+	rate, ok := sr.Rates[severity]
+	if !ok || rate >= 1 {
+		return true
+	}
+
+	random := sr.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+	return random() < rate
+}
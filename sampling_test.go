@@ -0,0 +1,50 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestSamplingReporter(t *testing.T) {
+	rr := &recordingReporter{}
+	sr := &SamplingReporter{
+		Reporter: rr,
+		Rates: map[string]float64{
+			"warning": 0.25,
+			"info":    0,
+		},
+		Rand: rand.New(rand.NewSource(1)).Float64,
+	}
+	ctx := context.Background()
+
+	const n = 4000
+	for i := 0; i < n; i++ {
+		sr.Report(ctx, errors.New("warning"), &BugsnagMetadata{Severity: "warning"})
+	}
+	warnings := len(rr.errs)
+	if warnings < n*0.2 || warnings > n*0.3 {
+		t.Errorf("expected about %d warnings to be sampled, got %d", n/4, warnings)
+	}
+
+	for i := 0; i < 100; i++ {
+		sr.Report(ctx, errors.New("info"), &BugsnagMetadata{Severity: "info"})
+		sr.Report(ctx, errors.New("error"), &BugsnagMetadata{Severity: "error"})
+		sr.Report(ctx, errors.New("no metadata"))
+		sr.Report(ctx, errors.New("unknown"), &BugsnagMetadata{Severity: "fatal"})
+	}
+	if got := len(rr.errs) - warnings; got != 300 {
+		t.Errorf("expected every error and unknown severity to pass and no info, got %d", got)
+	}
+}
+
+func TestSamplingReporterErrorMetadata(t *testing.T) {
+	rr := &recordingReporter{}
+	sr := &SamplingReporter{Reporter: rr, Rates: map[string]float64{"info": 0}}
+
+	sr.Report(context.Background(), WithReportMetadata(errors.New("cache miss"), &BugsnagMetadata{Severity: "info"}))
+	if len(rr.errs) != 0 {
+		t.Errorf("expected the severity attached to the error to be sampled, got %v", rr.errs)
+	}
+}
@@ -0,0 +1,31 @@
+package bugsnack
+
+import "strconv"
+
+// A SeverityReason tells bugsnag why an event has its severity, e.g.
+// because it was an unrecovered panic, and is shown on the event
+type SeverityReason struct {
+	Type       string            `json:"type"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// PanicReason is the reason for recovered panics, as reported by
+// ReportPanic
+func PanicReason() *SeverityReason {
+	return &SeverityReason{Type: "unhandledPanic"}
+}
+
+// ErrorReason is the reason for errors returned to and reported by
+// the application
+func ErrorReason() *SeverityReason {
+	return &SeverityReason{Type: "handledError"}
+}
+
+// HTTPStatusReason is the reason for errors which made a server answer
+// with the given status, typically a 5xx
+func HTTPStatusReason(status int) *SeverityReason {
+	return &SeverityReason{
+		Type:       "handledError",
+		Attributes: map[string]string{"statusCode": strconv.Itoa(status)},
+	}
+}
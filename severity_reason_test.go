@@ -0,0 +1,31 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSeverityReason(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	ctx := context.Background()
+
+	panicWith(er, "boom")
+	er.Report(ctx, errors.New("returned"), &BugsnagMetadata{SeverityReason: ErrorReason()})
+	er.Report(ctx, errors.New("upstream timeout"), &BugsnagMetadata{SeverityReason: HTTPStatusReason(http.StatusGatewayTimeout)})
+	er.Report(ctx, errors.New("no reason"))
+
+	for i, want := range []interface{}{
+		map[string]interface{}{"type": "unhandledPanic"},
+		map[string]interface{}{"type": "handledError"},
+		map[string]interface{}{"type": "handledError", "attributes": map[string]interface{}{"statusCode": "504"}},
+		nil,
+	} {
+		if got := sentEvent(t, doer, i)["severityReason"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("report %d: expected severityReason %v, got %v", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package bugsnack
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// filteredMarker replaces values which may hold personal data
+const filteredMarker = "[FILTERED]"
+
+// WithSQL returns a copy of meta (which may be nil) with a "database"
+// tab holding the query an error came from, and the type and value of
+// each argument. Strings and byte slices often hold personal data, so
+// their values are filtered, as are those of any other types (e.g.
+// net.IP); numbers, booleans, times, durations and NULLs are kept.
+//
+//	er.Report(ctx, err, bugsnack.WithSQL(nil, query, userID, email))
+func WithSQL(meta *BugsnagMetadata, query string, args ...interface{}) *BugsnagMetadata {
+	sqlArgs := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		sqlArgs = append(sqlArgs, sqlArg(arg))
+	}
+
+	database := &BugsnagMetadata{EventMetadata: &map[string]interface{}{
+		"database": map[string]interface{}{
+			"query": query,
+			"args":  sqlArgs,
+		},
+	}}
+	return mergeMetadata(meta, database)
+}
+
+// sqlArg describes a query argument, with its value scrubbed
+func sqlArg(arg interface{}) map[string]interface{} {
+	described := map[string]interface{}{}
+	if named, ok := arg.(sql.NamedArg); ok {
+		described["name"] = named.Name
+		arg = named.Value
+	}
+	described["type"] = fmt.Sprintf("%T", arg)
+
+	value := arg
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if v, err := valuer.Value(); err == nil {
+			value = v
+		}
+	}
+	switch value.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		described["value"] = value
+	case time.Time, time.Duration:
+		described["value"] = fmt.Sprint(value)
+	default:
+		described["value"] = filteredMarker
+	}
+	return described
+}
@@ -0,0 +1,47 @@
+package bugsnack
+
+import (
+	"database/sql"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithSQL(t *testing.T) {
+	at := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	query := "UPDATE users SET email = $1, password = $2, age = $3 WHERE id = $4 AND updated < $5"
+	md := WithSQL(&BugsnagMetadata{Severity: "warning"}, query,
+		"jane@example.com",
+		[]byte("hunter2"),
+		sql.NullInt64{Int64: 42, Valid: true},
+		sql.Named("id", 7),
+		at,
+		90*time.Second,
+		net.ParseIP("192.0.2.1"),
+		nil,
+		sql.NullString{String: "secret", Valid: true},
+	)
+
+	if md.Severity != "warning" {
+		t.Errorf("expected the given metadata to be kept, got %+v", md)
+	}
+	database := (*md.EventMetadata)["database"].(map[string]interface{})
+	if database["query"] != query {
+		t.Errorf("unexpected query %v", database["query"])
+	}
+	want := []interface{}{
+		map[string]interface{}{"type": "string", "value": "[FILTERED]"},
+		map[string]interface{}{"type": "[]uint8", "value": "[FILTERED]"},
+		map[string]interface{}{"type": "sql.NullInt64", "value": int64(42)},
+		map[string]interface{}{"name": "id", "type": "int", "value": 7},
+		map[string]interface{}{"type": "time.Time", "value": at.String()},
+		map[string]interface{}{"type": "time.Duration", "value": "1m30s"},
+		map[string]interface{}{"type": "net.IP", "value": "[FILTERED]"},
+		map[string]interface{}{"type": "<nil>", "value": nil},
+		map[string]interface{}{"type": "sql.NullString", "value": "[FILTERED]"},
+	}
+	if !reflect.DeepEqual(database["args"], want) {
+		t.Errorf("expected args %v, got %v", want, database["args"])
+	}
+}
@@ -0,0 +1,178 @@
+package bugsnack
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// stackTracer is implemented by pkg/errors errors
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// callerser is implemented by errors which record the raw program
+// counters of their stack, as returned by runtime.Callers
+type callerser interface {
+	Callers() []uintptr
+}
+
+// A stackFrame is a single resolved frame of a stack trace
+type stackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// stackOf returns the program counters of the stack carried
+// by err itself, if it has one
+func stackOf(err error) ([]uintptr, bool) {
+	switch e := err.(type) {
+	case stackTracer:
+		st := e.StackTrace()
+		pcs := make([]uintptr, len(st))
+		for i, f := range st {
+			pcs[i] = uintptr(f)
+		}
+		return pcs, true
+	case callerser:
+		return e.Callers(), true
+	}
+	return nil, false
+}
+
+// hasStack reports whether any error in err's chain carries a stack
+func hasStack(err error) bool {
+	for e := err; e != nil; e = unwrap(e) {
+		if _, ok := stackOf(e); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// callers returns the program counters of the calling goroutine's
+// stack, skipping skip frames above the caller of callers
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	return pcs[:runtime.Callers(skip+2, pcs)]
+}
+
+// errorStack returns the stack of the deepest error in err's chain
+// that carries one, so that it points at where the error originated,
+// falling back to pcs if there is none. Leading frames within bugsnack
+// itself (e.g. decorating reporters) are left out.
+func errorStack(err error, pcs []uintptr) []stackFrame {
+	for e := err; e != nil; e = unwrap(e) {
+		if stack, ok := stackOf(e); ok {
+			pcs = stack
+		}
+	}
+
+	stack := callersFrames(pcs)
+	for len(stack) > 1 && isInternal(stack[0]) {
+		stack = stack[1:]
+	}
+	return stack
+}
+
+// packagePath is the import path of this package, which may be
+// vendored under a different prefix
+var packagePath = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+strings.Index(name[slash:], ".")]
+}()
+
+// isInternal reports whether a frame belongs to bugsnack (or its
+// subpackages), not counting tests
+func isInternal(f stackFrame) bool {
+	if strings.HasSuffix(f.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(f.Function, packagePath+".") || strings.HasPrefix(f.Function, packagePath+"/")
+}
+
+// callersFrames resolves program counters into frames
+func callersFrames(pcs []uintptr) []stackFrame {
+	var stack []stackFrame
+	if len(pcs) == 0 {
+		return stack
+	}
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, stackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+		if !more {
+			return stack
+		}
+	}
+}
+
+// verboseMessage renders the outermost error in err's chain which
+// implements fmt.Formatter with %+v, which conventionally includes
+// its stack
+func verboseMessage(err error) (string, bool) {
+	for e := err; e != nil; e = unwrap(e) {
+		if f, ok := e.(fmt.Formatter); ok {
+			return fmt.Sprintf("%+v", f), true
+		}
+	}
+	return "", false
+}
+
+// unwrap understands both stdlib (Unwrap) and pkg/errors (Cause)
+// style wrapping
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+func formatStack(s []stackFrame) []map[string]interface{} {
+	var o []map[string]interface{}
+
+	for _, f := range s {
+		o = append(o, map[string]interface{}{
+			"method":     funcName(f.Function),
+			"file":       path.Base(f.File),
+			"lineNumber": f.Line,
+		})
+	}
+
+	return o
+}
+
+// capStack keeps the first max frames of a formatted stack, replacing
+// the rest with a single marker frame. A max of zero means no limit.
+func capStack(s []map[string]interface{}, max int) []map[string]interface{} {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	return append(s[:max:max], map[string]interface{}{
+		"method":     fmt.Sprintf("... %d frames omitted", len(s)-max),
+		"file":       "",
+		"lineNumber": 0,
+	})
+}
+
+// funcName strips the package path from a function name,
+// e.g. github.com/fromatob/bugsnack.(*BugsnagReporter).Report
+// becomes (*BugsnagReporter).Report
+func funcName(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	return name[strings.Index(name, ".")+1:]
+}
@@ -0,0 +1,81 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// callersError records its stack like errors from libraries
+// other than pkg/errors do
+type callersError struct {
+	pcs []uintptr
+}
+
+func (ce *callersError) Error() string { return "callers error" }
+
+func (ce *callersError) Callers() []uintptr { return ce.pcs }
+
+func newCallersError() error {
+	pcs := make([]uintptr, 32)
+	return &callersError{pcs: pcs[:runtime.Callers(1, pcs)]}
+}
+
+func TestCallersStack(t *testing.T) {
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), newCallersError())
+
+	exception := sentEvent(t, doer, 0)["exceptions"].([]interface{})[0].(map[string]interface{})
+	frames := exception["stacktrace"].([]interface{})
+	top := frames[0].(map[string]interface{})
+	if top["method"] != "newCallersError" || top["file"] != "stack_test.go" {
+		t.Errorf("expected the stack to start at newCallersError, got %v", top)
+	}
+	if next := frames[1].(map[string]interface{})["method"]; next != "TestCallersStack" {
+		t.Errorf("expected the caller next, got %v", next)
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	for name, want := range map[string]string{
+		"github.com/fromatob/bugsnack.(*BugsnagReporter).Report": "(*BugsnagReporter).Report",
+		"github.com/fromatob/bugsnack.TestFuncName.func1":        "TestFuncName.func1",
+		"main.main": "main",
+	} {
+		if got := funcName(name); got != want {
+			t.Errorf("funcName(%q) = %q, expected %q", name, got, want)
+		}
+	}
+}
+
+func recurse(depth int) error {
+	if depth == 0 {
+		pcs := make([]uintptr, 4096)
+		return &callersError{pcs: pcs[:runtime.Callers(1, pcs)]}
+	}
+	return recurse(depth - 1)
+}
+
+func TestMaxStackDepth(t *testing.T) {
+	err := recurse(2000)
+	total := len(err.(*callersError).pcs)
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer, MaxStackDepth: 50}
+	er.Report(context.Background(), err)
+
+	exception := sentEvent(t, doer, 0)["exceptions"].([]interface{})[0].(map[string]interface{})
+	frames := exception["stacktrace"].([]interface{})
+	if len(frames) != 51 {
+		t.Fatalf("expected 50 frames and a marker, got %d", len(frames))
+	}
+	if top := frames[0].(map[string]interface{})["method"]; top != "recurse" {
+		t.Errorf("expected the innermost frames to be kept, got %v", top)
+	}
+	want := fmt.Sprintf("... %d frames omitted", total-50)
+	if marker := frames[50].(map[string]interface{})["method"]; marker != want {
+		t.Errorf("expected %q, got %v", want, marker)
+	}
+}
@@ -0,0 +1,115 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statsdWriteTimeout bounds each packet write, so that reporting
+// never blocks for long
+const statsdWriteTimeout = 100 * time.Millisecond
+
+// dialStatsD is replaced in tests
+var dialStatsD = net.Dial
+
+// A StatsDReporter counts errors in StatsD over UDP, tagged (DogStatsD
+// style) with their class, severity and release stage. With Events
+// set, it also sends a DogStatsD event for each error.
+type StatsDReporter struct {
+	// Addr is the host:port of the StatsD server or Datadog agent
+	Addr string
+
+	// Metric is the name of the counter, defaulting to bugsnack.errors
+	Metric string
+
+	// ReleaseStage is the stage tag, unless the context has one
+	// (see WithReleaseStage)
+	ReleaseStage string
+
+	Events bool
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Report sends the error's metric (and event), ignoring any failure
+func (sr *StatsDReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = sr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE sends the error's metric (and event), returning any error
+// encountered. Private errors are skipped.
+func (sr *StatsDReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	if err == nil || isPrivate(err, metadata) {
+		return nil
+	}
+	conn, dialErr := sr.connect()
+	if dialErr != nil {
+		return dialErr
+	}
+
+	md := mergeMetadata(errorMetadata(err), metadataFrom(metadata))
+	stage := ContextReleaseStage(ctx)
+	if stage == "" {
+		stage = sr.ReleaseStage
+	}
+	tags := fmt.Sprintf("#class:%s,severity:%s,stage:%s",
+		statsdEscape(errorClass(err, md)), statsdEscape(severity(md)), statsdEscape(stage))
+
+	metric := sr.Metric
+	if metric == "" {
+		metric = "bugsnack.errors"
+	}
+	if err := statsdSend(conn, fmt.Sprintf("%s:1|c|%s", metric, tags)); err != nil {
+		return err
+	}
+
+	if !sr.Events {
+		return nil
+	}
+	title := errorClass(err, md)
+	text := strings.Replace(err.Error(), "\n", `\n`, -1)
+	return statsdSend(conn, fmt.Sprintf("_e{%d,%d}:%s|%s|t:%s|%s", len(title), len(text), title, text, alertType(severity(md)), tags))
+}
+
+// connect returns the connection to Addr, dialing it if there is none
+// yet, so that a failed dial (e.g. as DNS isn't up yet) is retried by
+// the next report
+func (sr *StatsDReporter) connect() (net.Conn, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.conn == nil {
+		conn, err := dialStatsD("udp", sr.Addr)
+		if err != nil {
+			return nil, err
+		}
+		sr.conn = conn
+	}
+	return sr.conn, nil
+}
+
+func statsdSend(conn net.Conn, packet string) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(statsdWriteTimeout)); err != nil {
+		return err
+	}
+	_, err := conn.Write([]byte(packet))
+	return err
+}
+
+// statsdEscape replaces characters with a meaning in DogStatsD tags
+var statsdEscape = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_").Replace
+
+// alertType maps a severity to a DogStatsD event alert type
+func alertType(severity string) string {
+	switch severity {
+	case "warning":
+		return "warning"
+	case "info":
+		return "info"
+	}
+	return "error"
+}
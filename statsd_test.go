@@ -0,0 +1,90 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStatsDReporter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sr := &StatsDReporter{Addr: conn.LocalAddr().String(), ReleaseStage: "production", Events: true}
+	ctx := WithReleaseStage(context.Background(), "staging")
+	if err := sr.ReportE(ctx, errors.New("disk full\non /data"), &BugsnagMetadata{ErrorClass: "io,disk", Severity: "warning"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sr.ReportE(context.Background(), errors.New("secret"), &BugsnagMetadata{Private: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sr.ReportE(context.Background(), errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"bugsnack.errors:1|c|#class:io_disk,severity:warning,stage:staging",
+		`_e{7,19}:io,disk|disk full\non /data|t:warning|#class:io_disk,severity:warning,stage:staging`,
+		"bugsnack.errors:1|c|#class:*errors.errorString,severity:error,stage:production",
+		"_e{19,7}:*errors.errorString|timeout|t:error|#class:*errors.errorString,severity:error,stage:production",
+	} {
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestStatsDReporterUnreachable(t *testing.T) {
+	sr := &StatsDReporter{Addr: "not a host:port"}
+	if err := sr.ReportE(context.Background(), errors.New("lost")); err == nil {
+		t.Error("expected an error for a bad address")
+	}
+}
+
+func TestStatsDReporterRedials(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	dials := 0
+	defer func(f func(string, string) (net.Conn, error)) { dialStatsD = f }(dialStatsD)
+	dialStatsD = func(network, addr string) (net.Conn, error) {
+		dials++
+		if dials == 1 {
+			return nil, errors.New("no such host")
+		}
+		return net.Dial(network, addr)
+	}
+
+	sr := &StatsDReporter{Addr: conn.LocalAddr().String()}
+	if err := sr.ReportE(context.Background(), errors.New("lost")); err == nil {
+		t.Error("expected the failed dial")
+	}
+	for i := 0; i < 2; i++ {
+		if err := sr.ReportE(context.Background(), errors.New("counted")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dials != 2 {
+		t.Errorf("expected a single redial, got %d dials", dials)
+	}
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadFrom(buf); err != nil {
+		t.Errorf("expected a packet once dialing worked, got %v", err)
+	}
+}
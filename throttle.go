@@ -0,0 +1,102 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A ThrottleReporter passes on the first occurrence of an error (keyed
+// by GroupingHash, or the error message) in each Interval, and counts
+// the rest. The count is attached to the next occurrence to get through,
+// or, if the error doesn't come up again, reported on its own once the
+// next report of any error finds its Interval over.
+//
+// It is meant to wrap a BugsnagReporter's Backup, so that an outage of
+// the primary backend doesn't turn into a flood on the fallback.
+type ThrottleReporter struct {
+	Reporter ErrorReporter
+	Interval time.Duration
+
+	mu   sync.Mutex
+	seen map[string]*throttleWindow
+}
+
+type throttleWindow struct {
+	start      time.Time
+	suppressed int
+
+	// the last suppressed report
+	err      error
+	metadata []interface{}
+}
+
+// Report sends the error on to the underlying Reporter, unless the same
+// error was already sent within the current Interval
+func (tr *ThrottleReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	key := groupingKey(err, metadataFrom(metadata))
+	t := now()
+
+	tr.mu.Lock()
+	if tr.seen == nil {
+		tr.seen = map[string]*throttleWindow{}
+	}
+	window, ok := tr.seen[key]
+	if ok && t.Sub(window.start) < tr.Interval {
+		window.suppressed++
+		window.err, window.metadata = err, metadata
+		tr.mu.Unlock()
+		return
+	}
+	tr.seen[key] = &throttleWindow{start: t}
+	expired := tr.forget(t)
+	tr.mu.Unlock()
+
+	for _, stale := range expired {
+		tr.Reporter.Report(ctx, stale.summarize(stale.err), stale.metadata...)
+	}
+	if ok && window.suppressed > 0 {
+		err = window.summarize(err)
+	}
+	tr.Reporter.Report(ctx, err, metadata...)
+}
+
+// Flush reports a summary of every error suppressed in the current
+// Intervals, then flushes the underlying Reporter
+func (tr *ThrottleReporter) Flush(ctx context.Context) error {
+	tr.mu.Lock()
+	var pending []*throttleWindow
+	for _, window := range tr.seen {
+		if window.suppressed > 0 {
+			pending = append(pending, window)
+		}
+	}
+	tr.seen = nil
+	tr.mu.Unlock()
+
+	for _, window := range pending {
+		tr.Reporter.Report(ctx, window.summarize(window.err), window.metadata...)
+	}
+	return Flush(ctx, tr.Reporter)
+}
+
+func (window *throttleWindow) summarize(err error) error {
+	return fmt.Errorf("%w (repeated %d times since %s)", err, window.suppressed, window.start.Format(time.RFC3339))
+}
+
+// forget drops expired windows, so they don't pile up, returning those
+// which suppressed reports, whose summaries are still to be sent
+func (tr *ThrottleReporter) forget(t time.Time) []*throttleWindow {
+	var expired []*throttleWindow
+	for key, window := range tr.seen {
+		if t.Sub(window.start) < tr.Interval {
+			continue
+		}
+		if window.suppressed > 0 {
+			expired = append(expired, window)
+		}
+		delete(tr.seen, key)
+	}
+	return expired
+}
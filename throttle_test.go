@@ -0,0 +1,74 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottledBackup(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	backup := &recordingReporter{}
+	er := &BugsnagReporter{
+		APIKey: testAPIKey,
+		Doer:   (&MockDoer{}).FailWith(errors.New("bugsnag is down")),
+		Backup: &ThrottleReporter{Reporter: backup, Interval: time.Minute},
+	}
+
+	for i := 0; i < 1000; i++ {
+		er.Report(context.Background(), errors.New("storm"))
+		clock = clock.Add(10 * time.Millisecond)
+	}
+	if len(backup.errs) != 1 {
+		t.Fatalf("expected a single backup report, got %d", len(backup.errs))
+	}
+
+	clock = clock.Add(time.Minute)
+	er.Report(context.Background(), errors.New("storm"))
+	if len(backup.errs) != 2 {
+		t.Fatalf("expected a summary once the interval passed, got %d reports", len(backup.errs))
+	}
+	if !strings.Contains(backup.errs[1].Error(), "repeated 999 times") {
+		t.Errorf("expected the summary to count suppressed reports, got %q", backup.errs[1])
+	}
+}
+
+func TestThrottleSummarizesExpiredWindows(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	backup := &recordingReporter{}
+	tr := &ThrottleReporter{Reporter: backup, Interval: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		tr.Report(ctx, errors.New("storm"))
+	}
+
+	// the storm is over, but it must still be accounted for
+	clock = clock.Add(2 * time.Minute)
+	tr.Report(ctx, errors.New("something else"))
+
+	if len(backup.errs) != 3 {
+		t.Fatalf("expected the storm, its summary and the new error, got %v", backup.errs)
+	}
+	if got := backup.errs[1].Error(); !strings.HasPrefix(got, "storm (repeated 4 times") {
+		t.Errorf("expected a summary of the expired window, got %q", got)
+	}
+	if got := backup.errs[2].Error(); got != "something else" {
+		t.Errorf("expected the new error as it is, got %q", got)
+	}
+
+	if err := tr.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(backup.errs) != 3 {
+		t.Errorf("expected the summary not to be sent twice, got %v", backup.errs)
+	}
+}
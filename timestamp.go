@@ -0,0 +1,40 @@
+package bugsnack
+
+import "time"
+
+// WithTimestamp wraps err with the time it occurred, for errors which
+// are reported some time later. Reports then carry both an occurredAt
+// and a reportedAt time under an "error" tab.
+//
+// Errors may also record the time themselves, by implementing
+// Timestamp() time.Time.
+func WithTimestamp(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &timestampError{error: err, t: now()}
+}
+
+// timestampError is an error carrying the time it occurred
+type timestampError struct {
+	error
+	t time.Time
+}
+
+func (te *timestampError) Timestamp() time.Time { return te.t }
+
+func (te *timestampError) Cause() error { return te.error }
+
+func (te *timestampError) Unwrap() error { return te.error }
+
+// occurredAt returns the time err occurred, going by the deepest error
+// in its chain which records one, or reportedAt if none does
+func occurredAt(err error, reportedAt time.Time) time.Time {
+	t := reportedAt
+	for e := err; e != nil; e = unwrap(e) {
+		if ts, ok := e.(interface{ Timestamp() time.Time }); ok {
+			t = ts.Timestamp()
+		}
+	}
+	return t
+}
@@ -0,0 +1,48 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type timestampedError struct {
+	at time.Time
+}
+
+func (te timestampedError) Error() string { return "queued job failed" }
+
+func (te timestampedError) Timestamp() time.Time { return te.at }
+
+func TestTimestamps(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	buffered := WithTimestamp(errors.New("buffered"))
+	if WithTimestamp(nil) != nil {
+		t.Error("expected nil errors to stay nil")
+	}
+	clock = clock.Add(time.Minute)
+
+	doer := &MockDoer{}
+	er := &BugsnagReporter{APIKey: testAPIKey, Doer: doer}
+	er.Report(context.Background(), buffered)
+	er.Report(context.Background(), fmt.Errorf("wrapped: %w", timestampedError{at: clock.Add(-time.Hour)}))
+	er.Report(context.Background(), errors.New("immediate"))
+
+	for i, want := range []string{"2017-05-05T12:00:00.000Z", "2017-05-05T11:01:00.000Z", "2017-05-05T12:01:00.000Z"} {
+		event := sentEvent(t, doer, i)
+		tab := event["metaData"].(map[string]interface{})["error"].(map[string]interface{})
+		if tab["occurredAt"] != want || tab["reportedAt"] != "2017-05-05T12:01:00.000Z" {
+			t.Errorf("report %d: expected occurredAt %s, got %v", i, want, tab)
+		}
+	}
+
+	exception := sentEvent(t, doer, 0)["exceptions"].([]interface{})[0].(map[string]interface{})
+	if exception["errorClass"] != "*errors.errorString" || exception["message"] != "buffered" {
+		t.Errorf("expected the wrapper to be invisible, got %v", exception)
+	}
+}
@@ -0,0 +1,43 @@
+package bugsnack
+
+import (
+	"context"
+	"time"
+)
+
+// A TimingReporter measures how long each report takes the underlying
+// Reporter, and passes it to Observe along with Name and the error
+// delivery failed with (always nil unless Reporter is an
+// ErrorReporterE), e.g. to record a latency histogram per backend.
+// Without Observe, errors are simply passed on.
+type TimingReporter struct {
+	Reporter ErrorReporter
+	Name     string
+	Observe  func(name string, d time.Duration, err error)
+}
+
+// Report sends the error on to the underlying Reporter, timing it
+func (tr *TimingReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = tr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE behaves like Report, but returns the underlying Reporter's
+// error, if it has one
+func (tr *TimingReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	start := now()
+	var reportErr error
+	if ere, ok := tr.Reporter.(ErrorReporterE); ok {
+		reportErr = ere.ReportE(ctx, err, metadata...)
+	} else {
+		tr.Reporter.Report(ctx, err, metadata...)
+	}
+	if tr.Observe != nil {
+		tr.Observe(tr.Name, now().Sub(start), reportErr)
+	}
+	return reportErr
+}
+
+// Flush flushes the underlying Reporter
+func (tr *TimingReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, tr.Reporter)
+}
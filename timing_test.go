@@ -0,0 +1,74 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowReporter takes its time, according to the stubbed clock
+type slowReporter struct {
+	stubReporter
+	clock *time.Time
+	delay time.Duration
+}
+
+func (sr *slowReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	*sr.clock = sr.clock.Add(sr.delay)
+	return sr.stubReporter.ReportE(ctx, err, metadata...)
+}
+
+func TestTimingReporter(t *testing.T) {
+	clock := time.Date(2017, 5, 5, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	type observation struct {
+		name string
+		d    time.Duration
+		err  error
+	}
+	var observed []observation
+	observe := func(name string, d time.Duration, err error) {
+		observed = append(observed, observation{name, d, err})
+	}
+
+	down := errors.New("bugsnag is down")
+	slow := &slowReporter{clock: &clock, delay: 150 * time.Millisecond}
+	tr := &TimingReporter{Reporter: slow, Name: "bugsnag", Observe: observe}
+	ctx := context.Background()
+
+	tr.Report(ctx, errors.New("first"))
+	slow.err, slow.delay = down, 2*time.Second
+	if err := tr.ReportE(ctx, errors.New("second")); err != down {
+		t.Errorf("expected the delivery error to be returned, got %v", err)
+	}
+	(&TimingReporter{Reporter: &recordingReporter{}, Name: "log", Observe: observe}).Report(ctx, errors.New("third"))
+
+	want := []observation{
+		{"bugsnag", 150 * time.Millisecond, nil},
+		{"bugsnag", 2 * time.Second, down},
+		{"log", 0, nil},
+	}
+	if len(observed) != len(want) {
+		t.Fatalf("expected %d observations, got %v", len(want), observed)
+	}
+	for i := range want {
+		if observed[i] != want[i] {
+			t.Errorf("expected %v, got %v", want[i], observed[i])
+		}
+	}
+}
+
+func TestTimingReporterWithoutObserve(t *testing.T) {
+	inner := &stubReporter{}
+	tr := &TimingReporter{Reporter: inner, Name: "bugsnag"}
+
+	if err := tr.ReportE(context.Background(), errors.New("unobserved")); err != nil {
+		t.Fatal(err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected the error to be passed on, got %d calls", inner.calls)
+	}
+}
@@ -0,0 +1,95 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceContext identifies the W3C Trace Context span an error
+// happened in
+type TraceContext struct {
+	TraceID    string
+	SpanID     string
+	TraceState string
+}
+
+type traceContextKey struct{}
+
+// WithTraceContext returns a copy of ctx carrying the trace context of
+// r's traceparent and tracestate headers. ctx is returned unchanged if
+// r has no valid traceparent.
+func WithTraceContext(ctx context.Context, r *http.Request) context.Context {
+	tc, ok := ParseTraceParent(r.Header.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	tc.TraceState = r.Header.Get("tracestate")
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFrom returns the trace context stored in ctx by
+// WithTraceContext, if any
+func TraceContextFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// ParseTraceParent parses a traceparent header value,
+// e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceParent(header string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || !isHexID(parts[0], 2) || parts[0] == "ff" ||
+		!isHexID(parts[1], 32) || !isHexID(parts[2], 16) || !isHexID(parts[3], 2) {
+		return TraceContext{}, false
+	}
+	// version 00 has exactly four fields, later ones may add more
+	if parts[0] == "00" && len(parts) != 4 {
+		return TraceContext{}, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return TraceContext{}, false
+	}
+	return TraceContext{TraceID: parts[1], SpanID: parts[2]}, true
+}
+
+// isHexID reports whether s is n lowercase hex digits
+func isHexID(s string, n int) bool {
+	if len(s) != n || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// A TraceReporter attaches the trace context stored in the context by
+// WithTraceContext under a "trace" tab, so that errors can be linked
+// to distributed traces
+type TraceReporter struct {
+	Reporter ErrorReporter
+}
+
+// Report sends the error on with the trace tab added
+func (tr *TraceReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	tc, ok := TraceContextFrom(ctx)
+	if !ok {
+		tr.Reporter.Report(ctx, err, metadata...)
+		return
+	}
+
+	tab := map[string]interface{}{
+		"trace_id": tc.TraceID,
+		"span_id":  tc.SpanID,
+	}
+	if tc.TraceState != "" {
+		tab["tracestate"] = tc.TraceState
+	}
+	trace := &BugsnagMetadata{EventMetadata: &map[string]interface{}{"trace": tab}}
+	tr.Reporter.Report(ctx, err, withMetadata(metadata, mergeMetadata(trace, metadataFrom(metadata)))...)
+}
+
+// Flush flushes the underlying Reporter
+func (tr *TraceReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, tr.Reporter)
+}
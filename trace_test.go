@@ -0,0 +1,49 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tc, ok := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected trace context %+v (%v)", tc, ok)
+	}
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if tc, ok := ParseTraceParent(header); ok {
+			t.Errorf("expected %q to be rejected, got %+v", header, tc)
+		}
+	}
+}
+
+func TestTraceReporter(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("tracestate", "congo=t61rcWkgMzE")
+	ctx := WithTraceContext(context.Background(), r)
+
+	rr := &recordingReporter{}
+	tr := &TraceReporter{Reporter: rr}
+	tr.Report(ctx, errors.New("trace test"))
+	tr.Report(context.Background(), errors.New("untraced"))
+
+	tab := (*rr.metadata[0].EventMetadata)["trace"].(map[string]interface{})
+	if tab["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || tab["span_id"] != "00f067aa0ba902b7" || tab["tracestate"] != "congo=t61rcWkgMzE" {
+		t.Errorf("unexpected trace tab %v", tab)
+	}
+	if md := rr.metadata[1]; md != nil && md.EventMetadata != nil {
+		t.Errorf("expected no trace tab without a trace context, got %v", *md.EventMetadata)
+	}
+}
@@ -0,0 +1,27 @@
+package bugsnack
+
+import "context"
+
+// A TransformReporter reshapes each error and its metadata before
+// passing them on, so that each branch of a MultiReporter can get
+// data suited to its backend, e.g. dropping bulky tabs from a log
+// file or renaming error classes for one service only. Errors the
+// Transform turns into nil are not reported.
+type TransformReporter struct {
+	Reporter  ErrorReporter
+	Transform func(err error, metadata []interface{}) (error, []interface{})
+}
+
+// Report sends the transformed error on to the underlying Reporter
+func (tr *TransformReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	err, metadata = tr.Transform(err, metadata)
+	if err == nil {
+		return
+	}
+	tr.Reporter.Report(ctx, err, metadata...)
+}
+
+// Flush flushes the underlying Reporter
+func (tr *TransformReporter) Flush(ctx context.Context) error {
+	return Flush(ctx, tr.Reporter)
+}
@@ -0,0 +1,43 @@
+package bugsnack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTransformReporter(t *testing.T) {
+	plain, shaped := &recordingReporter{}, &recordingReporter{}
+	er := &MultiReporter{Reporters: []ErrorReporter{
+		plain,
+		&TransformReporter{
+			Reporter: shaped,
+			Transform: func(err error, metadata []interface{}) (error, []interface{}) {
+				if err.Error() == "noise" {
+					return nil, nil
+				}
+				md := mergeMetadata(metadataFrom(metadata), &BugsnagMetadata{ErrorClass: "shaped"})
+				return fmt.Errorf("shaped: %w", err), withMetadata(metadata, md)
+			},
+		},
+	}}
+	ctx := context.Background()
+
+	original := errors.New("boom")
+	er.Report(ctx, original, &BugsnagMetadata{Context: "worker"})
+	er.Report(ctx, errors.New("noise"))
+
+	if len(plain.errs) != 2 || plain.errs[0] != original || plain.metadata[0].ErrorClass != "" {
+		t.Errorf("expected the other branch to get the errors untouched, got %v %+v", plain.errs, plain.metadata[0])
+	}
+	if len(shaped.errs) != 1 {
+		t.Fatalf("expected the transform to drop one error, got %v", shaped.errs)
+	}
+	if shaped.errs[0].Error() != "shaped: boom" || !errors.Is(shaped.errs[0], original) {
+		t.Errorf("expected the transformed error, got %v", shaped.errs[0])
+	}
+	if md := shaped.metadata[0]; md.ErrorClass != "shaped" || md.Context != "worker" {
+		t.Errorf("expected the transformed metadata, got %+v", md)
+	}
+}
@@ -0,0 +1,83 @@
+package bugsnack
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// afterFunc is time.AfterFunc, which tests replace
+var afterFunc = func(d time.Duration, f func()) interface{ Stop() bool } {
+	return time.AfterFunc(d, f)
+}
+
+// A Watchdog reports a possible hang if it isn't stopped in time:
+//
+//	w := bugsnack.NewWatchdog(er, 10*time.Minute)
+//	defer w.Done()
+//
+// The report carries a dump of every goroutine under a "goroutines"
+// tab, and a stack trace starting where the Watchdog was created.
+type Watchdog struct {
+	er      ErrorReporter
+	timeout time.Duration
+	pcs     []uintptr
+	timer   interface{ Stop() bool }
+
+	once sync.Once
+}
+
+// NewWatchdog starts a Watchdog reporting to er unless Done is
+// called within timeout
+func NewWatchdog(er ErrorReporter, timeout time.Duration) *Watchdog {
+	w := &Watchdog{er: er, timeout: timeout, pcs: callers(1)}
+	w.timer = afterFunc(timeout, w.fire)
+	return w
+}
+
+// Done stops the Watchdog. It may be called more than once.
+func (w *Watchdog) Done() {
+	w.once.Do(func() {
+		w.timer.Stop()
+	})
+}
+
+func (w *Watchdog) fire() {
+	w.once.Do(func() {
+		err := &watchdogError{timeout: w.timeout, pcs: w.pcs}
+		w.er.Report(context.Background(), err, &BugsnagMetadata{
+			ErrorClass: "bugsnack.Watchdog",
+			EventMetadata: &map[string]interface{}{
+				"goroutines": map[string]interface{}{
+					"dump": goroutineDump(),
+				},
+			},
+		})
+	})
+}
+
+// watchdogError is reported by a Watchdog which timed out
+type watchdogError struct {
+	timeout time.Duration
+	pcs     []uintptr
+}
+
+func (we *watchdogError) Error() string {
+	return fmt.Sprintf("possible deadlock or hang: not done after %s", we.timeout)
+}
+
+func (we *watchdogError) Callers() []uintptr { return we.pcs }
+
+// goroutineDump returns the stacks of all goroutines
+func goroutineDump() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
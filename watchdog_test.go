@@ -0,0 +1,70 @@
+package bugsnack
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeTimer struct {
+	d       time.Duration
+	f       func()
+	stopped bool
+}
+
+func (ft *fakeTimer) Stop() bool {
+	ft.stopped = true
+	return true
+}
+
+func stubAfterFunc(t *testing.T) *[]*fakeTimer {
+	var timers []*fakeTimer
+	orig := afterFunc
+	t.Cleanup(func() { afterFunc = orig })
+	afterFunc = func(d time.Duration, f func()) interface{ Stop() bool } {
+		ft := &fakeTimer{d: d, f: f}
+		timers = append(timers, ft)
+		return ft
+	}
+	return &timers
+}
+
+func TestWatchdogDone(t *testing.T) {
+	timers := stubAfterFunc(t)
+	rr := &recordingReporter{}
+
+	w := NewWatchdog(rr, time.Minute)
+	w.Done()
+	w.Done()
+
+	timer := (*timers)[0]
+	if timer.d != time.Minute || !timer.stopped {
+		t.Errorf("expected a stopped one minute timer, got %+v", timer)
+	}
+	// a timer firing as it is stopped must not report
+	timer.f()
+	if len(rr.errs) != 0 {
+		t.Errorf("expected no report, got %v", rr.errs)
+	}
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	timers := stubAfterFunc(t)
+	rr := &recordingReporter{}
+
+	w := NewWatchdog(rr, time.Minute)
+	(*timers)[0].f()
+	w.Done()
+
+	if len(rr.errs) != 1 || rr.errs[0].Error() != "possible deadlock or hang: not done after 1m0s" {
+		t.Fatalf("expected a hang report, got %v", rr.errs)
+	}
+	dump := (*rr.metadata[0].EventMetadata)["goroutines"].(map[string]interface{})["dump"].(string)
+	if !strings.Contains(dump, "goroutine ") || !strings.Contains(dump, "TestWatchdogTimeout") {
+		t.Errorf("expected a goroutine dump, got %q", dump)
+	}
+	stack := errorStack(rr.errs[0], nil)
+	if len(stack) == 0 || funcName(stack[0].Function) != "TestWatchdogTimeout" {
+		t.Errorf("expected the stack to start where the watchdog was created, got %v", stack)
+	}
+}
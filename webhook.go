@@ -0,0 +1,145 @@
+package bugsnack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// A WebhookReporter posts errors to an arbitrary HTTP endpoint, with
+// the body rendered from a template, e.g.
+//
+//	{"text": {{json .Message}}, "level": {{json .Severity}}}
+//
+// The template is executed with a WebhookEvent, and can use the json
+// function to encode values.
+type WebhookReporter struct {
+	// Doer sends the requests, defaulting to http.DefaultClient
+	Doer Doer
+
+	URL string
+
+	// Method defaults to POST
+	Method string
+
+	// Header is added to every request, e.g. a Content-Type
+	// or Authorization header
+	Header http.Header
+
+	Template string
+
+	once sync.Once
+	tmpl *template.Template
+	err  error
+}
+
+// A WebhookEvent is what a WebhookReporter's template is rendered with
+type WebhookEvent struct {
+	Message      string
+	ErrorClass   string
+	Severity     string
+	Context      string
+	GroupingHash string
+	Unhandled    bool
+	Time         time.Time
+
+	// Stack holds the frames of the stack trace, each with a method,
+	// file and lineNumber, as sent to bugsnag
+	Stack    []map[string]interface{}
+	Metadata map[string]interface{}
+	Tags     map[string]string
+}
+
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Report posts the error, ignoring any failure
+func (wr *WebhookReporter) Report(ctx context.Context, err error, metadata ...interface{}) {
+	_ = wr.ReportE(ctx, err, metadata...)
+}
+
+// ReportE posts the error, returning any error encountered.
+// Nil and private errors are skipped.
+func (wr *WebhookReporter) ReportE(ctx context.Context, err error, metadata ...interface{}) error {
+	if err == nil || isPrivate(err, metadata) {
+		return nil
+	}
+	wr.once.Do(func() {
+		wr.tmpl, wr.err = template.New("webhook").Funcs(webhookFuncs).Parse(wr.Template)
+	})
+	if wr.err != nil {
+		return wr.err
+	}
+
+	var pcs []uintptr
+	if !hasStack(err) {
+		pcs = callers(1)
+	}
+
+	var body bytes.Buffer
+	if err := wr.tmpl.Execute(&body, newWebhookEvent(err, pcs, metadataFrom(metadata))); err != nil {
+		return err
+	}
+
+	method := wr.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, wr.URL, &body)
+	if err != nil {
+		return err
+	}
+	for name, values := range wr.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	doer := wr.Doer
+	if doer == nil {
+		doer = defaultDoer
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, defaultResponseReadLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("could not report to webhook: %s: %s", resp.Status, respBody)
+	}
+	return nil
+}
+
+func newWebhookEvent(err error, pcs []uintptr, metadata *BugsnagMetadata) *WebhookEvent {
+	metadata = mergeMetadata(errorMetadata(err), metadata)
+	metadata.populateMetadata(err)
+
+	event := &WebhookEvent{
+		Message:      err.Error(),
+		ErrorClass:   metadata.ErrorClass,
+		Severity:     metadata.Severity,
+		Context:      metadata.Context,
+		GroupingHash: metadata.GroupingHash,
+		Unhandled:    metadata.Unhandled,
+		Time:         now(),
+		Stack:        formatStack(errorStack(err, pcs)),
+		Tags:         metadata.Tags,
+	}
+	if !IsZeroInterface(metadata.EventMetadata) {
+		event.Metadata = resolveThunks(*metadata.EventMetadata).(map[string]interface{})
+	}
+	return event
+}
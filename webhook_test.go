@@ -0,0 +1,81 @@
+package bugsnack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestWebhookReporter(t *testing.T) {
+	doer := &MockDoer{}
+	wr := &WebhookReporter{
+		Doer:   doer,
+		URL:    "https://hooks.example.com/errors",
+		Method: http.MethodPut,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Template: `{"text": {{json .Message}}, "level": {{json .Severity}}, "class": {{json .ErrorClass}},` +
+			` "top": {{json (index .Stack 0).method}}, "user": {{json .Metadata.user}}}`,
+	}
+
+	err := wr.ReportE(context.Background(), errors.New(`disk "data" is full`), &BugsnagMetadata{
+		Severity:      "warning",
+		EventMetadata: &map[string]interface{}{"user": map[string]interface{}{"id": 42}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := doer.Requests()[0]
+	if req.Method != http.MethodPut || req.URL.String() != "https://hooks.example.com/errors" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected request %s %s %v", req.Method, req.URL, req.Header)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(doer.Bodies()[0], &body); err != nil {
+		t.Fatalf("expected valid JSON, got %s: %v", doer.Bodies()[0], err)
+	}
+	if body["text"] != `disk "data" is full` || body["level"] != "warning" || body["class"] != "*errors.errorString" {
+		t.Errorf("unexpected body %v", body)
+	}
+	if body["top"] != "TestWebhookReporter" {
+		t.Errorf("expected the stack to start at the caller, got %v", body["top"])
+	}
+	if user, _ := body["user"].(map[string]interface{}); user["id"] != float64(42) {
+		t.Errorf("unexpected user %v", body["user"])
+	}
+}
+
+func TestWebhookReporterResolvesThunks(t *testing.T) {
+	doer := &MockDoer{}
+	wr := &WebhookReporter{Doer: doer, Template: `{{json .Metadata.query.plan}}`}
+
+	err := wr.ReportE(context.Background(), errors.New("slow query"), &BugsnagMetadata{EventMetadata: &map[string]interface{}{
+		"query": map[string]interface{}{"plan": func() interface{} { return "seq scan" }},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := string(doer.Bodies()[0]); body != `"seq scan"` {
+		t.Errorf("expected the thunk's result to be sent, got %s", body)
+	}
+}
+
+func TestWebhookReporterErrors(t *testing.T) {
+	wr := &WebhookReporter{Doer: &MockDoer{}, Template: "{{.Message"}
+	if err := wr.ReportE(context.Background(), errors.New("bad template")); err == nil {
+		t.Error("expected the template error")
+	}
+
+	wr = &WebhookReporter{Doer: (&MockDoer{}).RespondWith(http.StatusBadRequest, "invalid payload"), Template: "{{.Message}}"}
+	if err := wr.ReportE(context.Background(), errors.New("rejected")); err == nil {
+		t.Error("expected an error for a 400 response")
+	}
+
+	doer := &MockDoer{}
+	wr = &WebhookReporter{Doer: doer, Template: "{{.Message}}"}
+	if err := wr.ReportE(context.Background(), nil); err != nil || len(doer.Requests()) != 0 {
+		t.Errorf("expected a nil error to be skipped, got %v after %d requests", err, len(doer.Requests()))
+	}
+}